@@ -0,0 +1,207 @@
+package fig
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// setFromString parses value into the type of the field and assigns the result to it.
+// Supported are all scalar kinds, time.Duration, url.URL, implementations of
+// encoding.TextUnmarshaler and references to any of them.
+func setFromString(field reflect.Value, value string) error {
+	fieldType := field.Type()
+	switch {
+	case fieldType == durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+
+	case fieldType == urlType:
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(*parsed))
+		return nil
+
+	case reflect.PtrTo(fieldType).Implements(textUnmarshalerType):
+		unmarshaled := reflect.New(fieldType)
+		if err := unmarshaled.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+		field.Set(unmarshaled.Elem())
+		return nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, fieldType.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		parsed, err := strconv.ParseUint(value, 10, fieldType.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, fieldType.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+
+	case reflect.Complex64, reflect.Complex128:
+		parsed, err := strconv.ParseComplex(value, fieldType.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetComplex(parsed)
+
+	case reflect.Ptr:
+		elem := reflect.New(fieldType.Elem())
+		if err := setFromString(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+
+	default:
+		return fmt.Errorf("values of type %s can't be parsed from string", fieldType)
+	}
+	return nil
+}
+
+// setFromValue assigns value to the field converting it if it has different type:
+// strings and JSON numbers are parsed as by setFromString, numbers are converted between numeric kinds
+// if the value fits into the type of the field and slices are converted element by element.
+func setFromValue(field reflect.Value, value interface{}) error {
+	fieldType := field.Type()
+	valueOf := reflect.ValueOf(value)
+	if value != nil && valueOf.Type().AssignableTo(fieldType) {
+		field.Set(valueOf)
+		return nil
+	}
+	if fieldType.Kind() == reflect.Ptr && value != nil && valueOf.Kind() != reflect.Ptr {
+		elem := reflect.New(fieldType.Elem())
+		if err := setFromValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	if value != nil && isNumber(valueOf.Kind()) && isNumber(fieldType.Kind()) {
+		return setNumber(field, valueOf)
+	}
+
+	switch converted := value.(type) {
+	case json.Number:
+		return setFromString(field, converted.String())
+	case string:
+		return setFromString(field, converted)
+	case bool:
+		if fieldType.Kind() == reflect.Bool {
+			field.SetBool(converted)
+			return nil
+		}
+	case nil:
+		return fmt.Errorf("nil can't be converted to %s", fieldType)
+	case []interface{}:
+		if fieldType.Kind() == reflect.Slice {
+			elements := reflect.MakeSlice(fieldType, len(converted), len(converted))
+			for elementIndex, element := range converted {
+				if element == nil {
+					continue
+				}
+				if err := setFromValue(elements.Index(elementIndex), element); err != nil {
+					return fmt.Errorf("element %d: %v", elementIndex, err)
+				}
+			}
+			field.Set(elements)
+			return nil
+		}
+	}
+	return fmt.Errorf("value of type %T can't be converted to %s", value, fieldType)
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// setNumber converts number into numeric type of the field. Error is returned if the number
+// doesn't fit into the type, for example negative number into unsigned type or fraction into integer.
+func setNumber(field reflect.Value, number reflect.Value) error {
+	var asFloat float64
+	switch number.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		asFloat = float64(number.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		asFloat = float64(number.Uint())
+	default:
+		asFloat = number.Float()
+	}
+
+	fieldType := field.Type()
+	fits := true
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch number.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			fits = number.Uint() <= 1<<63-1 && !field.OverflowInt(int64(number.Uint()))
+		case reflect.Float32, reflect.Float64:
+			fits = asFloat == float64(int64(asFloat)) && !field.OverflowInt(int64(asFloat))
+		default:
+			fits = !field.OverflowInt(number.Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch number.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			fits = number.Int() >= 0 && !field.OverflowUint(uint64(number.Int()))
+		case reflect.Float32, reflect.Float64:
+			fits = asFloat >= 0 && asFloat == float64(uint64(asFloat)) && !field.OverflowUint(uint64(asFloat))
+		default:
+			fits = !field.OverflowUint(number.Uint())
+		}
+	default:
+		fits = !field.OverflowFloat(asFloat)
+	}
+	if !fits {
+		return fmt.Errorf("%v doesn't fit into %s", number, fieldType)
+	}
+	field.Set(number.Convert(fieldType))
+	return nil
+}
@@ -0,0 +1,300 @@
+package fig
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EnvPrecedence defines which value is used for `env` configuration if variable
+// is defined both in environment of the process and in file loaded by LoadDotenv.
+type EnvPrecedence int
+
+const (
+	// variables of the process (or env sources) override variables loaded from files
+	ProcessEnvFirst EnvPrecedence = iota
+	// variables loaded from files override variables of the process
+	DotenvFirst
+)
+
+// WithEnvPrecedence sets precedence of variables loaded by LoadDotenv, ProcessEnvFirst is used by default.
+func WithEnvPrecedence(precedence EnvPrecedence) Option {
+	return func(fig *Fig) {
+		fig.envPrecedence = precedence
+	}
+}
+
+// LoadDotenv parses file in .env format and uses its variables for `env` configuration.
+// Environment of the process is not changed. Variables of later loaded files override
+// variables of earlier ones. Supported are comments, `export` prefix, single-quoted values
+// that are taken literally, double-quoted values with escape sequences, multi-line quoted values
+// and interpolation of ${VAR} and $VAR in double-quoted and unquoted values. Variables are
+// interpolated from env sources of the injector and variables loaded earlier, as `env` configuration sees them.
+func (fig *Fig) LoadDotenv(reader io.Reader) error {
+	parsed, err := readDotenv(reader, lookupString(fig.lookupEnv))
+	if err != nil {
+		return err
+	}
+	fig.mu.Lock()
+	defer fig.mu.Unlock()
+	if fig.dotenv == nil {
+		fig.dotenv = make(map[string]string)
+	}
+	for key, value := range parsed {
+		fig.dotenv[key] = value
+	}
+	return nil
+}
+
+func readDotenv(reader io.Reader, lookup func(key string) (string, bool)) (map[string]string, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, FigError{Cause: "dotenv file can't be read: " + err.Error(), Error_: ErrorCannotBeRegistered}
+	}
+	return parseDotenv(string(content), lookup)
+}
+
+// lookupEnv returns value of environment variable from env sources taking into account variables loaded by LoadDotenv.
+func (fig *Fig) lookupEnv(key string) (interface{}, bool) {
+	precedence := ProcessEnvFirst
+	for injector := fig; injector != nil; injector = injector.parent {
+		if injector.envPrecedence != ProcessEnvFirst {
+			precedence = injector.envPrecedence
+			break
+		}
+	}
+	if precedence == ProcessEnvFirst {
+		if value, found := fig.lookupEnvSources(key); found {
+			return value, true
+		}
+	}
+	for injector := fig; injector != nil; injector = injector.parent {
+		injector.mu.RLock()
+		value, found := injector.dotenv[key]
+		injector.mu.RUnlock()
+		if found {
+			return value, true
+		}
+	}
+	if precedence == DotenvFirst {
+		return fig.lookupEnvSources(key)
+	}
+	return nil, false
+}
+
+type dotenvParser struct {
+	content string
+	pos     int
+	line    int
+	parsed  map[string]string
+	lookup  func(key string) (string, bool)
+}
+
+func parseDotenv(content string, lookup func(key string) (string, bool)) (map[string]string, error) {
+	parser := &dotenvParser{
+		content: strings.ReplaceAll(content, "\r\n", "\n"),
+		line:    1,
+		parsed:  make(map[string]string),
+		lookup:  lookup,
+	}
+	for parser.pos < len(parser.content) {
+		if err := parser.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+	return parser.parsed, nil
+}
+
+func (parser *dotenvParser) errorf(format string, args ...interface{}) error {
+	return FigError{
+		Cause:  fmt.Sprintf("dotenv line %d: ", parser.line) + fmt.Sprintf(format, args...),
+		Error_: ErrorCannotBeRegistered,
+	}
+}
+
+func (parser *dotenvParser) skipSpaces() {
+	for parser.pos < len(parser.content) && (parser.content[parser.pos] == ' ' || parser.content[parser.pos] == '\t') {
+		parser.pos++
+	}
+}
+
+// skipRestOfLine skips spaces and comment till the end of the line, anything else is an error.
+func (parser *dotenvParser) skipRestOfLine() error {
+	parser.skipSpaces()
+	if parser.pos < len(parser.content) && parser.content[parser.pos] == '#' {
+		for parser.pos < len(parser.content) && parser.content[parser.pos] != '\n' {
+			parser.pos++
+		}
+	}
+	if parser.pos < len(parser.content) {
+		if parser.content[parser.pos] != '\n' {
+			return parser.errorf("unexpected character %q", parser.content[parser.pos])
+		}
+		parser.pos++
+		parser.line++
+	}
+	return nil
+}
+
+func (parser *dotenvParser) parseLine() error {
+	parser.skipSpaces()
+	if parser.pos == len(parser.content) || parser.content[parser.pos] == '\n' || parser.content[parser.pos] == '#' {
+		return parser.skipRestOfLine()
+	}
+	if strings.HasPrefix(parser.content[parser.pos:], "export ") {
+		parser.pos += len("export ")
+		parser.skipSpaces()
+	}
+
+	keyStart := parser.pos
+	for parser.pos < len(parser.content) && isEnvKeyChar(parser.content[parser.pos], parser.pos == keyStart) {
+		parser.pos++
+	}
+	key := parser.content[keyStart:parser.pos]
+	if key == "" {
+		return parser.errorf("variable name expected")
+	}
+	parser.skipSpaces()
+	if parser.pos == len(parser.content) || parser.content[parser.pos] != '=' {
+		return parser.errorf("= expected after %s", key)
+	}
+	parser.pos++
+	parser.skipSpaces()
+
+	var value string
+	var err error
+	switch {
+	case parser.pos < len(parser.content) && parser.content[parser.pos] == '\'':
+		value, err = parser.parseSingleQuoted()
+	case parser.pos < len(parser.content) && parser.content[parser.pos] == '"':
+		value, err = parser.parseDoubleQuoted()
+	default:
+		value = parser.parseUnquoted()
+	}
+	if err != nil {
+		return err
+	}
+	parser.parsed[key] = value
+	return parser.skipRestOfLine()
+}
+
+func isEnvKeyChar(char byte, first bool) bool {
+	return isVariableChar(char, first) || !first && char == '.'
+}
+
+// isVariableChar is stricter than isEnvKeyChar, so $DIR.conf refers to DIR
+// and only ${app.dir} can refer to variables with dots.
+func isVariableChar(char byte, first bool) bool {
+	return char == '_' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' ||
+		!first && char >= '0' && char <= '9'
+}
+
+func (parser *dotenvParser) parseSingleQuoted() (string, error) {
+	startLine := parser.line
+	parser.pos++
+	end := strings.IndexByte(parser.content[parser.pos:], '\'')
+	if end < 0 {
+		parser.line = startLine
+		return "", parser.errorf("single quote is not closed")
+	}
+	value := parser.content[parser.pos : parser.pos+end]
+	parser.line += strings.Count(value, "\n")
+	parser.pos += end + 1
+	return value, nil
+}
+
+func (parser *dotenvParser) parseDoubleQuoted() (string, error) {
+	startLine := parser.line
+	parser.pos++
+	var value strings.Builder
+	for parser.pos < len(parser.content) {
+		char := parser.content[parser.pos]
+		switch {
+		case char == '"':
+			parser.pos++
+			return value.String(), nil
+		case char == '\\' && parser.pos+1 < len(parser.content):
+			parser.pos++
+			switch escaped := parser.content[parser.pos]; escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'r':
+				value.WriteByte('\r')
+			case '"', '\\', '$':
+				value.WriteByte(escaped)
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(escaped)
+			}
+			parser.pos++
+		case char == '$':
+			value.WriteString(parser.parseVariable())
+		default:
+			if char == '\n' {
+				parser.line++
+			}
+			value.WriteByte(char)
+			parser.pos++
+		}
+	}
+	parser.line = startLine
+	return "", parser.errorf("double quote is not closed")
+}
+
+// parseUnquoted reads value till the end of the line or comment that starts after space or tab.
+func (parser *dotenvParser) parseUnquoted() string {
+	var value strings.Builder
+	for parser.pos < len(parser.content) {
+		char := parser.content[parser.pos]
+		if char == '\n' || char == '#' && (value.Len() == 0 || strings.HasSuffix(value.String(), " ") ||
+			strings.HasSuffix(value.String(), "\t")) {
+			break
+		}
+		if char == '$' {
+			value.WriteString(parser.parseVariable())
+			continue
+		}
+		value.WriteByte(char)
+		parser.pos++
+	}
+	return strings.TrimRight(value.String(), " \t")
+}
+
+// parseVariable returns value of ${VAR} or $VAR that starts at current position. Variables defined
+// earlier in the same file take precedence, undefined variables are replaced with empty string.
+func (parser *dotenvParser) parseVariable() string {
+	parser.pos++
+	braced := parser.pos < len(parser.content) && parser.content[parser.pos] == '{'
+	if braced {
+		parser.pos++
+	}
+	nameStart := parser.pos
+	isNameChar := isVariableChar
+	if braced {
+		isNameChar = isEnvKeyChar
+	}
+	for parser.pos < len(parser.content) && isNameChar(parser.content[parser.pos], parser.pos == nameStart) {
+		parser.pos++
+	}
+	name := parser.content[nameStart:parser.pos]
+	if braced {
+		if parser.pos < len(parser.content) && parser.content[parser.pos] == '}' {
+			parser.pos++
+		} else {
+			return "${" + name
+		}
+	}
+	if name == "" {
+		if braced {
+			return "${}"
+		}
+		return "$"
+	}
+	if value, found := parser.parsed[name]; found {
+		return value
+	}
+	value, _ := parser.lookup(name)
+	return value
+}
@@ -0,0 +1,105 @@
+package fig
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	content := `# comment
+export HOST=localhost
+PORT = 8080 # inline comment
+URL=http://${HOST}:$PORT/path#fragment
+EMPTY=
+SINGLE='literal ${HOST} \n'
+DOUBLE="line\tone\n\"quoted\" \$HOST ${HOST}"
+MULTI="first
+second"
+MULTI_SINGLE='a
+b'
+FROM_ENV=${FIG_DOTENV_PROCESS}${FIG_DOTENV_UNDEFINED}
+DIR=/etc
+FILE=$DIR.conf
+app.dir=/opt
+APP_FILE=${app.dir}/app.conf
+TAB=a	#comment
+`
+	lookup := func(key string) (string, bool) {
+		if key == "FIG_DOTENV_PROCESS" {
+			return "process", true
+		}
+		return "", false
+	}
+	parsed, err := parseDotenv(content, lookup)
+	FatalIfError(func() error {
+		return err
+	})
+	expected := map[string]string{
+		"HOST":         "localhost",
+		"PORT":         "8080",
+		"URL":          "http://localhost:8080/path#fragment",
+		"EMPTY":        "",
+		"SINGLE":       `literal ${HOST} \n`,
+		"DOUBLE":       "line\tone\n\"quoted\" $HOST localhost",
+		"MULTI":        "first\nsecond",
+		"MULTI_SINGLE": "a\nb",
+		"FROM_ENV":     "process",
+		"DIR":          "/etc",
+		"FILE":         "/etc.conf",
+		"app.dir":      "/opt",
+		"APP_FILE":     "/opt/app.conf",
+		"TAB":          "a",
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("Unexpected variables: %#v", parsed)
+	}
+}
+
+func TestParseDotenv_Incorrect(t *testing.T) {
+	for _, content := range []string{
+		"KEY",
+		"=value",
+		"KEY='value",
+		"A=1\nKEY=\"value\nnext",
+		"KEY='value' trailing",
+		"1KEY=value",
+	} {
+		_, err := parseDotenv(content, os.LookupEnv)
+		if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotBeRegistered {
+			t.Errorf("Error expected for %q: %v", content, err)
+		}
+	}
+	_, err := parseDotenv("A=1\nKEY=\"value\nnext", os.LookupEnv)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Line of error expected: %v", err)
+	}
+}
+
+func TestLoadDotenv_Precedence(t *testing.T) {
+	os.Setenv("FIG_DOTENV_BOTH", "process")
+	defer os.Unsetenv("FIG_DOTENV_BOTH")
+	os.Unsetenv("FIG_DOTENV_FILE")
+	dotenv := "FIG_DOTENV_BOTH=file\nFIG_DOTENV_FILE=file\n"
+
+	for precedence, expected := range map[EnvPrecedence]string{ProcessEnvFirst: "process", DotenvFirst: "file"} {
+		injector := New(false, WithEnvPrecedence(precedence))
+		FatalIfError(func() error {
+			return injector.LoadDotenv(strings.NewReader(dotenv))
+		})
+		holder := &struct {
+			Both string `fig:"env[FIG_DOTENV_BOTH]"`
+			File string `fig:"env[FIG_DOTENV_FILE]"`
+		}{}
+		FatalIfError(func() error {
+			return injector.Child().Initialize(holder)
+		})
+		if holder.Both != expected || holder.File != "file" {
+			t.Errorf("Unexpected values for precedence %d: %#v", precedence, holder)
+		}
+	}
+	if _, found := os.LookupEnv("FIG_DOTENV_FILE"); found {
+		t.Error("Environment of the process must not be changed")
+	}
+}
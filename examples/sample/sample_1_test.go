@@ -44,7 +44,7 @@ func init() {
 	os.Setenv("ENV_NAME", "DEV")
 }
 
-func ExampleOfSimpleInjectionOnDependenciesAndEnvVar() {
+func Example_ofSimpleInjectionOnDependenciesAndEnvVar() {
 	injector := fig.New(false)
 	err := injector.Register(
 		&MemUserRepo{message: "mes_1"},
@@ -29,7 +29,7 @@ it will try to inject all fields of struct.
 So because we have field 'MemUserRepo' which has reference struct type and this struct was not registered explicitly
 it will be created by 'fig' and injected automatically.
 */
-func ExampleSimpleInjection() {
+func Example_simpleInjection() {
 	os.Setenv("DB_URL", "some:db:connection")
 	defer os.Unsetenv("DB_URL")
 
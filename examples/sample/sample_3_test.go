@@ -13,7 +13,7 @@ We create our 'fig' injector with 'true' flag provided into the constructor func
 it will try to inject only fields that marked with 'fig' tag.
 Also we user 'skip' configuration that allow us to explicitly mark fields that we do not want to be injected.
 */
-func ExampleSimpleInjectionOnlyIfFigTagPresent() {
+func Example_simpleInjectionOnlyIfFigTagPresent() {
 	injector := fig.New(true)
 	FatalIfError(func() error {
 		return injector.Register(
@@ -6,7 +6,7 @@ import (
 	"github.com/pavelmemory/fig"
 )
 
-func ExampleInjectionOfMapsSlicesAndChannels() {
+func Example_injectionOfMapsSlicesAndChannels() {
 	injector := fig.New(false)
 
 	usefulStruct := struct {
@@ -6,7 +6,7 @@ import (
 	"github.com/pavelmemory/fig"
 )
 
-func ExampleInjectionByRegisteredKeyValuePair() {
+func Example_injectionByRegisteredKeyValuePair() {
 	injector := fig.New(false)
 
 	usefulStruct := struct {
@@ -0,0 +1,185 @@
+package fig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// WithFlagSet sets flag set used by `flag` configuration, flag.CommandLine is used by default.
+func WithFlagSet(flagSet *flag.FlagSet) Option {
+	return func(fig *Fig) {
+		fig.flagSet = flagSet
+	}
+}
+
+func (fig *Fig) flags() *flag.FlagSet {
+	for injector := fig; injector != nil; injector = injector.parent {
+		if injector.flagSet != nil {
+			return injector.flagSet
+		}
+	}
+	return flag.CommandLine
+}
+
+// flagValue is flag.Value of flags defined by BindFlags. It accepts only values
+// that can be assigned to the field, so malformed values are reported by flag set on parsing.
+type flagValue struct {
+	fieldType reflect.Type
+	value     string
+}
+
+func (fv *flagValue) String() string {
+	if fv == nil {
+		return ""
+	}
+	return fv.value
+}
+
+func (fv *flagValue) Set(value string) error {
+	if err := setFromString(reflect.New(fv.fieldType).Elem(), value); err != nil {
+		return err
+	}
+	fv.value = value
+	return nil
+}
+
+// IsBoolFlag allows to set flags of bool fields without value, e.g. -debug
+func (fv *flagValue) IsBoolFlag() bool {
+	return fv.fieldType.Kind() == reflect.Bool
+}
+
+// BindFlags defines flags for all fields with `flag` configuration of holders, registered objects
+// and structs they refer to. Text of `usage` configuration is used as usage of the flag and value
+// of `default` configuration as its default. Flags that are already defined are not changed.
+// BindFlags must be called before flag set is parsed.
+func (fig *Fig) BindFlags(holders ...interface{}) error {
+	var types []reflect.Type
+	for _, holder := range holders {
+		if holder == nil {
+			return FigError{Cause: "nil cannot be holder", Error_: ErrorCannotBeHolder}
+		}
+		types = append(types, reflect.TypeOf(holder))
+	}
+	for injector := fig; injector != nil; injector = injector.parent {
+		injector.mu.RLock()
+		for _, regType := range injector.registrationOrder {
+			if _, isProvider := injector.registered[regType].(*provider); !isProvider {
+				types = append(types, reflect.TypeOf(injector.registered[regType]))
+			}
+		}
+		injector.mu.RUnlock()
+	}
+
+	visited := make(map[reflect.Type]bool)
+	for _, holderType := range types {
+		if err := fig.bindFlags(holderType, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fig *Fig) bindFlags(holderType reflect.Type, visited map[reflect.Type]bool) error {
+	for holderType.Kind() == reflect.Ptr {
+		holderType = holderType.Elem()
+	}
+	if holderType.Kind() != reflect.Struct || visited[holderType] {
+		return nil
+	}
+	visited[holderType] = true
+
+	for fieldIndex := 0; fieldIndex < holderType.NumField(); fieldIndex++ {
+		field := holderType.Field(fieldIndex)
+		fieldType := field.Type
+		if lazy, isLazy := lazyOf(reflect.New(fieldType).Elem()); isLazy {
+			fieldType = lazy.valueType()
+		}
+		name, found, err := getFigTagConfig(field.Tag, FLAG_TAG_KEY)
+		if err != nil {
+			return err
+		}
+		if found && fig.flags().Lookup(name) == nil {
+			usage, _, err := getFigTagConfig(field.Tag, USAGE_TAG_KEY)
+			if err != nil {
+				return err
+			}
+			value := &flagValue{fieldType: fieldType}
+			if defaultValue, found, _ := getFigTagConfig(field.Tag, DEFAULT_TAG_KEY); found {
+				value.value = defaultValue
+			}
+			fig.flags().Var(value, name, usage)
+		}
+		if err := fig.bindFlags(fieldType, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type InjectStepFlagValueSetup struct {
+	fig                *Fig
+	tag                reflect.StructTag
+	holderElementField reflect.Value
+	fieldName          string
+	skip               bool
+	reason             InjectionReason
+	// name of the flag
+	source string
+}
+
+func NewFlagValueSetup(fig *Fig, tag reflect.StructTag, holderElementField reflect.Value, fieldName string) *InjectStepFlagValueSetup {
+	return &InjectStepFlagValueSetup{fig: fig, tag: tag, holderElementField: holderElementField, fieldName: fieldName}
+}
+
+// Do sets value of the flag if it was set on command line. Otherwise value is taken
+// from environment variable if `env` configuration is defined or from `default` configuration.
+func (flagSetup *InjectStepFlagValueSetup) Do() error {
+	// flag value is set into Lazy when it is resolved
+	if _, isLazy := lazyOf(flagSetup.holderElementField); isLazy {
+		return nil
+	}
+	name, found, err := getFigTagConfig(flagSetup.tag, FLAG_TAG_KEY)
+	if err != nil || !found {
+		return err
+	}
+	flagSet := flagSetup.fig.flags()
+	definedFlag := flagSet.Lookup(name)
+	if definedFlag == nil {
+		return FigError{
+			Cause:  fmt.Sprintf("Flag -%s of field %s is not defined, use BindFlags to define it", name, flagSetup.fieldName),
+			Error_: ErrorIncorrectTagConfiguration,
+		}
+	}
+
+	isSet := false
+	flagSet.Visit(func(visited *flag.Flag) {
+		isSet = isSet || visited.Name == name
+	})
+	if !isSet {
+		if _, hasEnv, _ := getFigTagConfig(flagSetup.tag, ENV_TAG_KEY); hasEnv {
+			return nil
+		}
+		flagSetup.skip = true
+		defaultSet, err := setDefaultValue(flagSetup.tag, flagSetup.holderElementField, flagSetup.fieldName, FLAG_TAG_KEY+"["+name+"]")
+		if defaultSet && err == nil {
+			flagSetup.reason, flagSetup.source = ReasonDefault, name
+		}
+		return err
+	}
+
+	flagSetup.skip = true
+	flagSetup.reason, flagSetup.source = ReasonFlag, name
+	if err := setFromString(flagSetup.holderElementField, definedFlag.Value.String()); err != nil {
+		return FigError{
+			Cause: fmt.Sprintf("Flag -%s can't be assigned to field %s of type %s: %v",
+				name, flagSetup.fieldName, flagSetup.holderElementField.Type(), err),
+			Error_: ErrorIncorrectTagConfiguration,
+		}
+	}
+	return nil
+}
+
+func (flagSetup *InjectStepFlagValueSetup) Break() bool {
+	return flagSetup.skip
+}
@@ -0,0 +1,93 @@
+package fig
+
+import (
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagConfig struct {
+	Port    int           `fig:"flag[port] usage[port to listen] env[FIG_FLAG_PORT] default[80]"`
+	Host    string        `fig:"flag[host] env[FIG_FLAG_HOST] default[localhost]"`
+	Timeout time.Duration `fig:"flag[timeout] default[5s]"`
+	Debug   bool          `fig:"flag[debug]"`
+}
+
+type flagServer struct {
+	Config *flagConfig
+}
+
+func newFlagInjector(t *testing.T, args ...string) *Fig {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	injector := New(false, WithFlagSet(flagSet))
+	FatalIfError(func() error {
+		return injector.Register(new(flagServer))
+	})
+	FatalIfError(func() error {
+		return injector.BindFlags()
+	})
+	if err := flagSet.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return injector
+}
+
+func TestInitialize_Flags(t *testing.T) {
+	os.Setenv("FIG_FLAG_PORT", "8000")
+	os.Setenv("FIG_FLAG_HOST", "example.com")
+	defer os.Unsetenv("FIG_FLAG_PORT")
+	defer os.Unsetenv("FIG_FLAG_HOST")
+	injector := newFlagInjector(t, "-port", "9000", "-debug")
+
+	holder := &struct {
+		Server *flagServer
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	conf := holder.Server.Config
+	if conf.Port != 9000 || conf.Host != "example.com" || conf.Timeout != 5*time.Second || !conf.Debug {
+		t.Errorf("Unexpected configuration: %#v", conf)
+	}
+	if usage := injector.flags().Lookup("port").Usage; usage != "port to listen" {
+		t.Errorf("Unexpected usage: %s", usage)
+	}
+	if defaultValue := injector.flags().Lookup("timeout").DefValue; defaultValue != "5s" {
+		t.Errorf("Unexpected default value: %s", defaultValue)
+	}
+}
+
+func TestInitialize_FlagsMalformedAndUnknown(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	injector := New(false, WithFlagSet(flagSet))
+	FatalIfError(func() error {
+		return injector.BindFlags(new(flagConfig))
+	})
+	if err := flagSet.Parse([]string{"-port", "abc"}); err == nil {
+		t.Error("Malformed value of flag must be rejected on parsing")
+	}
+
+	err := injector.Initialize(&struct {
+		Name string `fig:"flag[name]"`
+	}{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorIncorrectTagConfiguration ||
+		!strings.Contains(figErr.Cause, "-name") {
+		t.Errorf("Error about not defined flag expected: %v", err)
+	}
+
+	flagSet.String("level", "high", "")
+	FatalIfError(func() error {
+		return flagSet.Parse([]string{"-level", "low"})
+	})
+	err = injector.Initialize(&struct {
+		Level int `fig:"flag[level]"`
+	}{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorIncorrectTagConfiguration {
+		t.Errorf("Error about incorrect value of flag expected: %v", err)
+	}
+}
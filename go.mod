@@ -0,0 +1,3 @@
+module github.com/pavelmemory/fig
+
+go 1.18
@@ -0,0 +1,177 @@
+package fig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// InjectionReason describes why a value was chosen for injection into the field.
+type InjectionReason string
+
+const (
+	// implementation chosen by `impl` configuration
+	ReasonImpl InjectionReason = "impl"
+	// implementation chosen by `qual` configuration
+	ReasonQual InjectionReason = "qual"
+	// candidate marked as primary
+	ReasonPrimary InjectionReason = "primary"
+	// the only registered candidate
+	ReasonSoleCandidate InjectionReason = "sole candidate"
+	// not registered struct created and assembled by fig
+	ReasonAutoCreated InjectionReason = "auto-created"
+	// all registered candidates injected with `all` configuration
+	ReasonAll InjectionReason = "all"
+	// all registered candidates injected with `qualified` configuration
+	ReasonQualified InjectionReason = "qualified"
+	// new map, slice or channel
+	ReasonCollection InjectionReason = "collection"
+	// value registered with RegisterValue
+	ReasonRegisteredValue InjectionReason = "reg"
+	// value of command-line flag
+	ReasonFlag InjectionReason = "flag"
+	// value of environment variable
+	ReasonEnv InjectionReason = "env"
+	// value set by handler registered with RegisterTagHandler
+	ReasonTagHandler InjectionReason = "tag handler"
+	// value of `default` configuration
+	ReasonDefault InjectionReason = "default"
+	// context passed to InitializeContext
+	ReasonContext InjectionReason = "context"
+	// handle that resolves value on demand
+	ReasonLazy InjectionReason = "lazy"
+)
+
+// Graph describes registered objects and values injected into their fields.
+// Only objects that were already assembled have their fields described.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+}
+
+// GraphNode is a registered object or result of a provider.
+type GraphNode struct {
+	// type the object is registered with
+	Type string `json:"type"`
+	// signature of constructor function if object is provided
+	Provider string `json:"provider,omitempty"`
+	// fields of the object or parameters of the constructor function
+	Fields []GraphField `json:"fields,omitempty"`
+}
+
+// GraphField is a field of the object or parameter of the provider that fig injected.
+type GraphField struct {
+	Name   string          `json:"name"`
+	Type   string          `json:"type"`
+	Reason InjectionReason `json:"reason"`
+	// types of injected objects or key of injected value
+	Targets []string `json:"targets,omitempty"`
+}
+
+// Graph returns description of all registered objects in order of their registration.
+func (fig *Fig) Graph() Graph {
+	fig.mu.RLock()
+	defer fig.mu.RUnlock()
+	graph := Graph{Nodes: make([]GraphNode, 0, len(fig.registrationOrder))}
+	for _, regType := range fig.registrationOrder {
+		if node, found := fig.injections[regType]; found {
+			graph.Nodes = append(graph.Nodes, node)
+			continue
+		}
+		node := GraphNode{Type: regType.String()}
+		if prov, isProvider := fig.registered[regType].(*provider); isProvider {
+			node.Provider = prov.constructor.Type().String()
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	return graph
+}
+
+func (fig *Fig) recordInjections(regType reflect.Type, prov *provider, fields []GraphField) {
+	node := GraphNode{Type: regType.String(), Fields: fields}
+	if prov != nil {
+		node.Provider = prov.constructor.Type().String()
+	}
+	fig.mu.Lock()
+	fig.injections[regType] = node
+	fig.mu.Unlock()
+}
+
+func describeInjection(name string, field reflect.Value, reason InjectionReason, source string) GraphField {
+	graphField := GraphField{Name: name, Type: field.Type().String(), Reason: reason}
+	switch {
+	case source != "":
+		graphField.Targets = []string{source}
+	case reason == ReasonCollection:
+	case reason == ReasonLazy:
+		if lazy, ok := lazyOf(field); ok {
+			graphField.Targets = []string{lazy.valueType().String()}
+		}
+	case field.Kind() == reflect.Slice:
+		for elementIndex := 0; elementIndex < field.Len(); elementIndex++ {
+			graphField.Targets = append(graphField.Targets, describeTarget(field.Index(elementIndex)))
+		}
+	case field.Kind() == reflect.Map:
+		for _, key := range field.MapKeys() {
+			graphField.Targets = append(graphField.Targets, describeTarget(field.MapIndex(key)))
+		}
+		sort.Strings(graphField.Targets)
+	default:
+		graphField.Targets = []string{describeTarget(field)}
+	}
+	return graphField
+}
+
+func describeTarget(value reflect.Value) string {
+	if value.Kind() == reflect.Interface && !value.IsNil() {
+		return value.Elem().Type().String()
+	}
+	return value.Type().String()
+}
+
+func isDependency(reason InjectionReason) bool {
+	switch reason {
+	case ReasonRegisteredValue, ReasonFlag, ReasonEnv, ReasonDefault, ReasonCollection, ReasonTagHandler:
+		return false
+	default:
+		return true
+	}
+}
+
+// WriteDOT renders the graph in Graphviz DOT format. Dependencies are rendered as edges,
+// injected values are listed in the label of the node.
+func (graph Graph) WriteDOT(w io.Writer) error {
+	var dot strings.Builder
+	dot.WriteString("digraph fig {\n")
+	for _, node := range graph.Nodes {
+		label := []string{node.Type}
+		if node.Provider != "" {
+			label = append(label, node.Provider)
+		}
+		for _, field := range node.Fields {
+			if !isDependency(field.Reason) {
+				label = append(label, fmt.Sprintf("%s: %s %s", field.Name, field.Reason, strings.Join(field.Targets, ", ")))
+			}
+		}
+		fmt.Fprintf(&dot, "\t%q [shape=box, label=%q];\n", node.Type, strings.Join(label, "\n"))
+		for _, field := range node.Fields {
+			if isDependency(field.Reason) {
+				for _, target := range field.Targets {
+					fmt.Fprintf(&dot, "\t%q -> %q [label=%q];\n", node.Type, target, fmt.Sprintf("%s (%s)", field.Name, field.Reason))
+				}
+			}
+		}
+	}
+	dot.WriteString("}\n")
+	_, err := io.WriteString(w, dot.String())
+	return err
+}
+
+// WriteJSON renders the graph as indented JSON.
+func (graph Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
@@ -0,0 +1,141 @@
+package fig
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type graphConfig struct {
+	Port int    `fig:"env[FIG_GRAPH_PORT] default[8080]"`
+	Name string `fig:"reg[name]"`
+}
+
+type graphCache struct {
+	Entries map[string]string
+}
+
+type graphService struct {
+	Getter   StringGetter `fig:"impl[github.com/pavelmemory/fig/StringGetterWithoutQualifier]"`
+	Store    *providedStore
+	Handlers []Handler `fig:"all"`
+	Cache    *graphCache
+	Skipped  *graphCache `fig:"skip[true]"`
+}
+
+func newGraphInjector() *Fig {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(
+			new(graphConfig),
+			new(graphService),
+			&StringGetterWithQualifier{qualifier: "q"},
+			new(StringGetterWithoutQualifier),
+			new(handlerFirst),
+		)
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func(cfg *graphConfig) *providedStore {
+			return &providedStore{Config: &providedConfig{DSN: cfg.Name}}
+		})
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func() *handlerThird {
+			return new(handlerThird)
+		})
+	})
+	FatalIfError(func() error {
+		return injector.RegisterValue("name", "graph")
+	})
+	return injector
+}
+
+func TestGraph(t *testing.T) {
+	os.Unsetenv("FIG_GRAPH_PORT")
+	injector := newGraphInjector()
+
+	graph := injector.Graph()
+	if len(graph.Nodes) != 7 || graph.Nodes[5].Provider != "func(*fig.graphConfig) *fig.providedStore" || graph.Nodes[1].Fields != nil {
+		t.Fatalf("Not assembled objects must be described without fields: %#v", graph)
+	}
+
+	FatalIfError(func() error {
+		return injector.Initialize(&struct{}{})
+	})
+
+	graph = injector.Graph()
+	expected := []GraphNode{
+		{Type: "*fig.graphConfig", Fields: []GraphField{
+			{Name: "Port", Type: "int", Reason: ReasonDefault, Targets: []string{"FIG_GRAPH_PORT"}},
+			{Name: "Name", Type: "string", Reason: ReasonRegisteredValue, Targets: []string{"name"}},
+		}},
+		{Type: "*fig.graphService", Fields: []GraphField{
+			{Name: "Getter", Type: "fig.StringGetter", Reason: ReasonImpl, Targets: []string{"*fig.StringGetterWithoutQualifier"}},
+			{Name: "Store", Type: "*fig.providedStore", Reason: ReasonSoleCandidate, Targets: []string{"*fig.providedStore"}},
+			{Name: "Handlers", Type: "[]fig.Handler", Reason: ReasonAll, Targets: []string{"*fig.handlerFirst", "*fig.handlerThird"}},
+			{Name: "Cache", Type: "*fig.graphCache", Reason: ReasonAutoCreated, Targets: []string{"*fig.graphCache"}},
+		}},
+		{Type: "*fig.StringGetterWithQualifier"},
+		{Type: "*fig.StringGetterWithoutQualifier"},
+		{Type: "*fig.handlerFirst"},
+		{Type: "*fig.providedStore", Provider: "func(*fig.graphConfig) *fig.providedStore", Fields: []GraphField{
+			{Name: "#0", Type: "*fig.graphConfig", Reason: ReasonSoleCandidate, Targets: []string{"*fig.graphConfig"}},
+		}},
+		{Type: "*fig.handlerThird", Provider: "func() *fig.handlerThird", Fields: []GraphField{}},
+	}
+	if !reflect.DeepEqual(graph.Nodes, expected) {
+		t.Errorf("Unexpected graph:\n%#v\nexpected:\n%#v", graph.Nodes, expected)
+	}
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	injector := newGraphInjector()
+	FatalIfError(func() error {
+		return injector.Initialize(&struct{}{})
+	})
+
+	var dot bytes.Buffer
+	FatalIfError(func() error {
+		return injector.Graph().WriteDOT(&dot)
+	})
+
+	for _, expected := range []string{
+		"digraph fig {\n",
+		"\t\"*fig.graphConfig\" [shape=box, label=\"*fig.graphConfig\\nPort: default FIG_GRAPH_PORT\\nName: reg name\"];\n",
+		"\t\"*fig.graphService\" -> \"*fig.StringGetterWithoutQualifier\" [label=\"Getter (impl)\"];\n",
+		"\t\"*fig.graphService\" -> \"*fig.handlerThird\" [label=\"Handlers (all)\"];\n",
+		"\t\"*fig.providedStore\" [shape=box, label=\"*fig.providedStore\\nfunc(*fig.graphConfig) *fig.providedStore\"];\n",
+		"\t\"*fig.providedStore\" -> \"*fig.graphConfig\" [label=\"#0 (sole candidate)\"];\n",
+		"}\n",
+	} {
+		if !strings.Contains(dot.String(), expected) {
+			t.Errorf("DOT output doesn't contain %q:\n%s", expected, dot.String())
+		}
+	}
+}
+
+func TestGraph_WriteJSON(t *testing.T) {
+	injector := newGraphInjector()
+	FatalIfError(func() error {
+		return injector.Initialize(&struct{}{})
+	})
+
+	var encoded bytes.Buffer
+	FatalIfError(func() error {
+		return injector.Graph().WriteJSON(&encoded)
+	})
+
+	var decoded Graph
+	FatalIfError(func() error {
+		return json.Unmarshal(encoded.Bytes(), &decoded)
+	})
+	if len(decoded.Nodes) != 7 || decoded.Nodes[1].Fields[0].Reason != ReasonImpl {
+		t.Errorf("Unexpected graph decoded from JSON: %s", encoded.String())
+	}
+	if !strings.Contains(encoded.String(), `"reason": "sole candidate"`) {
+		t.Errorf("Unexpected JSON: %s", encoded.String())
+	}
+}
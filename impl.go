@@ -1,45 +1,136 @@
 package fig
 
 import (
+	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type Qualifier interface {
 	Qualify() string
 }
 
+// Primary implementation is injected when there are multiple candidates
+// and neither `impl` nor `qual` configuration is provided.
+type Primary interface {
+	Primary() bool
+}
+
+// Initializer is called by Fig after all fields of the registered object are injected.
+type Initializer interface {
+	Init() error
+}
+
+// ContextInitializer is Initializer that gets context of the initialization.
+// Injector can be used from Init with this context, see InitializeContext.
+type ContextInitializer interface {
+	Init(ctx context.Context) error
+}
+
+// Closer is called by Fig for every initialized object on Close.
+type Closer interface {
+	Close() error
+}
+
 const (
 	// fig tag itself
 	FIG_TAG = "fig"
 	// configurations for fig tag
-	IMPL_TAG_KEY     = "impl"
-	ENV_TAG_KEY      = "env"
-	SKIP_TAG_KEY     = "skip"
-	REG_TAG_KEY      = "reg"
-	QUAL_TAG_KEY     = "qual"
-	SIZE_TAG_KEY     = "size"
-	CAPACITY_TAG_KEY = "cap"
+	IMPL_TAG_KEY      = "impl"
+	ENV_TAG_KEY       = "env"
+	SKIP_TAG_KEY      = "skip"
+	REG_TAG_KEY       = "reg"
+	QUAL_TAG_KEY      = "qual"
+	SIZE_TAG_KEY      = "size"
+	CAPACITY_TAG_KEY  = "cap"
+	DEFAULT_TAG_KEY   = "default"
+	REQUIRED_TAG_KEY  = "required"
+	ALL_TAG_KEY       = "all"
+	QUALIFIED_TAG_KEY = "qualified"
+	FLAG_TAG_KEY      = "flag"
+	USAGE_TAG_KEY     = "usage"
 )
 
 type Fig struct {
 	injectOnlyIfFigTagProvided bool
-	registered                 map[reflect.Type]interface{}
-	assembled                  map[reflect.Type]bool
-	registeredValues           map[string]interface{}
+	// explicitInterfaces disables injection of objects into fields of interfaces they are not bound to
+	explicitInterfaces bool
+	// flagSet is used for lookup of flags, if it is nil the parent one or flag.CommandLine is used
+	flagSet *flag.FlagSet
+	// envPrecedence defines if variables loaded by LoadDotenv override variables of the process
+	envPrecedence EnvPrecedence
+	// envSources replace environment of the process if they are not nil
+	envSources []ValueSource
+	// regSources are used if value is not registered
+	regSources []ValueSource
+	// parent is used for lookups of objects and values not registered in this injector
+	parent *Fig
+	// constructionMu is held while registered objects are assembled or constructed by providers,
+	// initialization of holders doesn't acquire it if all needed objects are ready
+	constructionMu sync.Mutex
+	// mu guards all fields below
+	mu                sync.RWMutex
+	registered        map[reflect.Type]interface{}
+	registrationOrder []reflect.Type
+	// registrations counts registrations of every type, so assembly of replaced object
+	// doesn't change state of the one registered instead of it
+	registrations    map[reflect.Type]int
+	assembled        map[reflect.Type]bool
+	ready            map[reflect.Type]bool
+	scopes           map[reflect.Type]scope
+	bindings         map[reflect.Type][]reflect.Type
+	registeredValues map[string]interface{}
+	tagHandlers      map[string]TagHandler
+	dotenv           map[string]string
+	components       []interface{}
+	injections       map[reflect.Type]GraphNode
+}
+
+// Option configures injector created by New.
+type Option func(fig *Fig)
+
+// WithoutImplicitInterfaces disables injection of registered objects into fields of interfaces
+// they implement, only interfaces named in RegisterAs are used.
+func WithoutImplicitInterfaces() Option {
+	return func(fig *Fig) {
+		fig.explicitInterfaces = true
+	}
 }
 
-func New(injectOnlyIfFigTagProvided bool) *Fig {
-	return &Fig{
+func New(injectOnlyIfFigTagProvided bool, opts ...Option) *Fig {
+	fig := &Fig{
 		injectOnlyIfFigTagProvided: injectOnlyIfFigTagProvided,
 		registered:                 make(map[reflect.Type]interface{}),
+		registrations:              make(map[reflect.Type]int),
 		assembled:                  make(map[reflect.Type]bool),
+		ready:                      make(map[reflect.Type]bool),
+		scopes:                     make(map[reflect.Type]scope),
+		bindings:                   make(map[reflect.Type][]reflect.Type),
 		registeredValues:           make(map[string]interface{}),
+		tagHandlers:                make(map[string]TagHandler),
+		injections:                 make(map[reflect.Type]GraphNode),
 	}
+	for _, opt := range opts {
+		opt(fig)
+	}
+	return fig
+}
+
+// Child creates injector that can use all objects and values registered in this injector
+// and its parents. Objects and values registered in the child override the ones registered
+// in the parent, but they are never visible to the parent. Objects registered in the parent
+// are assembled by the parent, so they can't depend on objects registered in the child.
+func (fig *Fig) Child() *Fig {
+	child := New(fig.injectOnlyIfFigTagProvided)
+	child.explicitInterfaces = fig.explicitInterfaces
+	child.parent = fig
+	return child
 }
 
 var (
@@ -48,6 +139,15 @@ var (
 	ErrorCannotDecideImplementation = errors.New("not able to get value to inject")
 	ErrorRegisteredValueOverridden  = errors.New("already registered value was overridden")
 	ErrorIncorrectTagConfiguration  = errors.New("invalid `fig` tag configuration")
+	ErrorProviderFailed             = errors.New("provider was not able to construct value")
+	ErrorRequiredValueMissing       = errors.New("required values are missing")
+	ErrorInitializationFailed       = errors.New("initialization of value failed")
+	ErrorCloseFailed                = errors.New("close of value failed")
+	ErrorDuplicateQualifier         = errors.New("multiple implementations have same qualifier")
+	ErrorDependencyCycle            = errors.New("dependency cycle detected")
+	ErrorRequestScopeMissing        = errors.New("request scope is not available")
+	ErrorTagHandlerFailed           = errors.New("tag handler was not able to set value")
+	ErrorCannotBeInjected           = errors.New("value can't be injected into the field")
 )
 
 type FigError struct {
@@ -60,7 +160,27 @@ func (fe FigError) Error() string {
 }
 
 func (fig *Fig) Register(impls ...interface{}) error {
+	return fig.registerAll(impls, singletonScope)
+}
+
+// RegisterPrototype registers templates of objects that are not shared: every injection
+// gets its own shallow copy of the template with all fields injected. Instances are not
+// closed by Close, they are owned by objects they are injected into.
+func (fig *Fig) RegisterPrototype(templates ...interface{}) error {
+	return fig.registerAll(templates, prototypeScope)
+}
+
+// RegisterRequestScoped registers templates of objects that are shared only during single request:
+// copy of the template is assembled once per scope created by NewRequestScope.
+// Such objects can be injected only by InitializeContext.
+func (fig *Fig) RegisterRequestScoped(templates ...interface{}) error {
+	return fig.registerAll(templates, requestScope)
+}
+
+func (fig *Fig) registerAll(impls []interface{}, regScope scope) error {
 	// Crowdbotics
+	fig.mu.Lock()
+	defer fig.mu.Unlock()
 	for _, impl := range impls {
 		implType := reflect.TypeOf(impl)
 		if implType == nil {
@@ -69,7 +189,7 @@ func (fig *Fig) Register(impls ...interface{}) error {
 
 		if implType.Kind() == reflect.Struct ||
 			implType.Kind() == reflect.Ptr && implType.Elem().Kind() == reflect.Struct {
-			fig.registered[implType] = impl
+			fig.register(implType, impl, regScope)
 		} else {
 			return FigError{Cause: "only structs and references to structs can be registered", Error_: ErrorCannotBeRegistered}
 		}
@@ -77,6 +197,200 @@ func (fig *Fig) Register(impls ...interface{}) error {
 	return nil
 }
 
+// register keeps order of registration, so objects are always assembled and
+// chosen as candidates in the same order. Registration of already registered type
+// replaces the object, but keeps its original position. Must be called with mu locked.
+func (fig *Fig) register(regType reflect.Type, regObject interface{}, regScope scope) {
+	if _, found := fig.registered[regType]; !found {
+		fig.registrationOrder = append(fig.registrationOrder, regType)
+	}
+	fig.registered[regType] = regObject
+	fig.registrations[regType]++
+	delete(fig.assembled, regType)
+	delete(fig.ready, regType)
+	fig.scopes[regType] = regScope
+	delete(fig.bindings, regType)
+}
+
+// RegisterAs registers impl that is injected into fields of interfaces passed
+// as nil references to them, e.g. (*io.Writer)(nil), but not into fields of other interfaces it implements.
+func (fig *Fig) RegisterAs(impl interface{}, ifaces ...interface{}) error {
+	implType := reflect.TypeOf(impl)
+	if implType == nil {
+		return FigError{Cause: "nil cannot be registered as injectable value", Error_: ErrorCannotBeRegistered}
+	}
+	if implType.Kind() != reflect.Struct &&
+		(implType.Kind() != reflect.Ptr || implType.Elem().Kind() != reflect.Struct) {
+		return FigError{Cause: "only structs and references to structs can be registered", Error_: ErrorCannotBeRegistered}
+	}
+	if len(ifaces) == 0 {
+		return FigError{Cause: "at least one interface must be provided for " + implType.String(), Error_: ErrorCannotBeRegistered}
+	}
+	bound := make([]reflect.Type, 0, len(ifaces))
+	for _, iface := range ifaces {
+		ifaceType := reflect.TypeOf(iface)
+		if ifaceType == nil || ifaceType.Kind() != reflect.Ptr || ifaceType.Elem().Kind() != reflect.Interface {
+			return FigError{
+				Cause:  fmt.Sprintf("interface must be provided as nil reference to it, e.g. (*io.Writer)(nil): %v", ifaceType),
+				Error_: ErrorCannotBeRegistered,
+			}
+		}
+		if !implType.Implements(ifaceType.Elem()) {
+			return FigError{
+				Cause:  fmt.Sprintf("%s doesn't implement %s", implType, ifaceType.Elem()),
+				Error_: ErrorCannotBeRegistered,
+			}
+		}
+		bound = append(bound, ifaceType.Elem())
+	}
+	fig.mu.Lock()
+	defer fig.mu.Unlock()
+	fig.register(implType, impl, singletonScope)
+	fig.bindings[implType] = bound
+	return nil
+}
+
+// boundTo reports if object of registered type can be injected into field of target type.
+// Objects registered with RegisterAs are bound only to named interfaces, others are bound
+// to all interfaces they implement unless WithoutImplicitInterfaces option is used.
+func (fig *Fig) boundTo(regType reflect.Type, targetType reflect.Type) bool {
+	if targetType.Kind() != reflect.Interface || regType == targetType {
+		return true
+	}
+	fig.mu.RLock()
+	bound, explicit := fig.bindings[regType]
+	fig.mu.RUnlock()
+	if !explicit {
+		return !fig.explicitInterfaces
+	}
+	for _, iface := range bound {
+		if iface == targetType {
+			return true
+		}
+	}
+	return false
+}
+
+type provider struct {
+	constructor reflect.Value
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Provide registers constructor function which result will be used as injectable value.
+// Parameters of the function are resolved from registered objects and
+// function itself is called only once at time the result is needed for the first time.
+// Function must return struct, reference to struct or interface and optionally an error.
+func (fig *Fig) Provide(constructor interface{}) error {
+	return fig.registerProvider(constructor, singletonScope)
+}
+
+// ProvidePrototype registers constructor function that is called for every injection of its result.
+func (fig *Fig) ProvidePrototype(constructor interface{}) error {
+	return fig.registerProvider(constructor, prototypeScope)
+}
+
+// ProvideRequestScoped registers constructor function that is called once per scope created by NewRequestScope.
+// Parameters of the function can be of request scoped types or context.Context.
+func (fig *Fig) ProvideRequestScoped(constructor interface{}) error {
+	return fig.registerProvider(constructor, requestScope)
+}
+
+func (fig *Fig) registerProvider(constructor interface{}, regScope scope) error {
+	constructorType := reflect.TypeOf(constructor)
+	if constructorType == nil {
+		return FigError{Cause: "nil cannot be registered as provider", Error_: ErrorCannotBeRegistered}
+	}
+	if constructorType.Kind() != reflect.Func {
+		return FigError{Cause: "only functions can be registered as providers: " + constructorType.String(), Error_: ErrorCannotBeRegistered}
+	}
+	if constructorType.IsVariadic() {
+		return FigError{Cause: "variadic functions can't be registered as providers: " + constructorType.String(), Error_: ErrorCannotBeRegistered}
+	}
+	if constructorType.NumOut() < 1 || constructorType.NumOut() > 2 ||
+		constructorType.NumOut() == 2 && constructorType.Out(1) != errorType {
+		return FigError{
+			Cause:  "provider must return single value or value and error: " + constructorType.String(),
+			Error_: ErrorCannotBeRegistered,
+		}
+	}
+
+	resultType := constructorType.Out(0)
+	if resultType.Kind() == reflect.Struct ||
+		resultType.Kind() == reflect.Interface ||
+		resultType.Kind() == reflect.Ptr && resultType.Elem().Kind() == reflect.Struct {
+		fig.mu.Lock()
+		defer fig.mu.Unlock()
+		fig.register(resultType, &provider{constructor: reflect.ValueOf(constructor)}, regScope)
+		return nil
+	}
+	return FigError{
+		Cause:  "only structs, references to structs and interfaces can be provided: " + constructorType.String(),
+		Error_: ErrorCannotBeRegistered,
+	}
+}
+
+func (fig *Fig) provide(resultType reflect.Type, prov *provider, registration int, asm *assembly) (interface{}, error) {
+	provided, injections, err := fig.construct(prov, asm)
+	if err != nil {
+		return nil, err
+	}
+	// result is available to Init of itself, but provider is restored if Init fails, so it is called again
+	fig.ifRegistered(resultType, registration, func() {
+		fig.registered[resultType] = provided
+		fig.assembled[resultType] = true
+	})
+	if err := fig.initComponent(resultType, provided, registration, asm); err != nil {
+		fig.ifRegistered(resultType, registration, func() {
+			fig.registered[resultType] = prov
+			delete(fig.assembled, resultType)
+		})
+		return nil, err
+	}
+	fig.recordInjections(resultType, prov, injections)
+	return provided, nil
+}
+
+// construct resolves parameters of the provider and calls it.
+func (fig *Fig) construct(prov *provider, asm *assembly) (interface{}, []GraphField, error) {
+	constructorType := prov.constructor.Type()
+	if err := asm.pushNode(constructorType); err != nil {
+		return nil, nil, err
+	}
+	args := make([]reflect.Value, constructorType.NumIn())
+	injections := make([]GraphField, 0, len(args))
+	for argIndex := range args {
+		argType := constructorType.In(argIndex)
+		asm.push(argType.String())
+		arg := reflect.New(argType).Elem()
+		argSetup := newValueSetup(fig, "", arg, true, asm)
+		if err := argSetup.Do(); err != nil {
+			return nil, nil, err
+		}
+		args[argIndex] = arg
+		if argSetup.reason != "" {
+			injections = append(injections, describeInjection(fmt.Sprintf("#%d", argIndex), arg, argSetup.reason, ""))
+		}
+		asm.truncate(asm.len() - 1)
+	}
+
+	results := prov.constructor.Call(args)
+	if len(results) == 2 && !results[1].IsNil() {
+		return nil, nil, FigError{
+			Cause:  fmt.Sprintf("Provider %s failed: %v", constructorType, results[1].Interface()),
+			Error_: ErrorProviderFailed,
+		}
+	}
+	if (results[0].Kind() == reflect.Ptr || results[0].Kind() == reflect.Interface) && results[0].IsNil() {
+		return nil, nil, FigError{
+			Cause:  fmt.Sprintf("Provider %s returned nil", constructorType),
+			Error_: ErrorProviderFailed,
+		}
+	}
+	asm.truncate(asm.len() - 1)
+	return results[0].Interface(), injections, nil
+}
+
 func (fig *Fig) RegisterValue(key string, value interface{}) error {
 	if value == nil {
 		return FigError{
@@ -84,6 +398,8 @@ func (fig *Fig) RegisterValue(key string, value interface{}) error {
 			Error_: ErrorCannotBeRegistered,
 		}
 	}
+	fig.mu.Lock()
+	defer fig.mu.Unlock()
 	if _, found := fig.registeredValues[key]; found {
 		fig.registeredValues[key] = value
 		return FigError{
@@ -94,6 +410,19 @@ func (fig *Fig) RegisterValue(key string, value interface{}) error {
 	return nil
 }
 
+// registeredValue looks up value registered in the injector or its parents and then in reg sources.
+func (fig *Fig) registeredValue(key string) (interface{}, bool) {
+	for injector := fig; injector != nil; injector = injector.parent {
+		injector.mu.RLock()
+		value, found := injector.registeredValues[key]
+		injector.mu.RUnlock()
+		if found {
+			return value, true
+		}
+	}
+	return fig.lookupRegSources(key)
+}
+
 func (fig *Fig) RegisterValues(keyValues map[string]interface{}) error {
 	for key, value := range keyValues {
 		if err := fig.RegisterValue(key, value); err != nil {
@@ -103,21 +432,84 @@ func (fig *Fig) RegisterValues(keyValues map[string]interface{}) error {
 	return nil
 }
 
+// Initialize injects values into fields of holder. It doesn't panic because of incorrect configuration,
+// panics of reflect package are returned as FigError with ErrorCannotBeInjected.
 func (fig *Fig) Initialize(holder interface{}) error {
-	assemblingChain := make([]string, 0)
-	err := fig.initialize(holder, &assemblingChain)
-	if err != nil {
-		if len(assemblingChain) > 0 {
-			figErr := err.(FigError)
-			figErr.Cause = strings.Join(assemblingChain, " -> ") + "=> " + figErr.Cause
-			return figErr
+	return fig.InitializeContext(context.Background(), holder)
+}
+
+// InitializeContext initializes holder as Initialize does, but ctx is injected into fields and
+// parameters of constructor functions of type context.Context. Objects registered with
+// RegisterRequestScoped and ProvideRequestScoped can be injected if ctx was created by NewRequestScope.
+// Context passed by Fig to constructor functions and Init methods tells that objects are being
+// constructed by the caller, so they are injected as they are instead of waiting until their
+// construction is finished. This is how they can use the injector.
+func (fig *Fig) InitializeContext(ctx context.Context, holder interface{}) (err error) {
+	if ctx == nil {
+		return FigError{Cause: "nil cannot be used as context", Error_: ErrorCannotBeHolder}
+	}
+	asm := newAssembly(ctx, new([]string))
+	defer recoverReflectPanic(&err, asm)
+	err = fig.initialize(holder, asm)
+	if figErr, ok := err.(FigError); ok && asm.len() > 0 {
+		figErr.Cause = asm.describe(figErr.Cause)
+		return figErr
+	}
+	return err
+}
+
+// recoverReflectPanic converts *reflect.ValueError panic (method of reflect.Value called on value
+// of wrong kind) into FigError with assembling chain, other panics are propagated. Must be called with defer.
+func recoverReflectPanic(err *error, asm *assembly) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	valueErr, isValueErr := recovered.(*reflect.ValueError)
+	if !isValueErr {
+		panic(recovered)
+	}
+	message := valueErr.Error()
+	if asm.len() > 0 {
+		message = asm.describe(message)
+	}
+	*err = FigError{Cause: message, Error_: ErrorCannotBeInjected}
+}
+
+// resolveValue sets value into the field as if it was a field of holder passed to InitializeContext.
+func (fig *Fig) resolveValue(ctx context.Context, field reflect.Value, tag reflect.StructTag) (err error) {
+	fieldName := field.Type().String()
+	asm := newAssembly(ctx, &[]string{fieldName})
+	tagHandlerSetup := NewTagHandlerSetup(fig, tag, field, fieldName)
+	registeredValueSetup := NewRegisteredValueSetup(fig, tag, field, fieldName)
+	flagValueSetup := NewFlagValueSetup(fig, tag, field, fieldName)
+	envValueSetup := NewEnvValueSetup(fig, tag, field, fieldName)
+	valueSetup := newValueSetup(fig, tag, field, false, asm)
+	defer recoverReflectPanic(&err, asm)
+	err = NewStepMachine().Add(
+		NewTagValidation(fig, tag, fieldName),
+		tagHandlerSetup,
+		registeredValueSetup,
+		flagValueSetup,
+		envValueSetup,
+		valueSetup,
+	).Do()
+	if err == nil && tagHandlerSetup.reason == "" && registeredValueSetup.reason == "" &&
+		flagValueSetup.reason == "" && envValueSetup.reason == "" && valueSetup.reason == "" {
+		// fields of such types are left untouched by Initialize, but there is nothing to return here
+		return FigError{
+			Cause:  "Nothing to resolve value of " + fieldName + " from, use `reg`, `flag` or `env` configuration",
+			Error_: ErrorCannotDecideImplementation,
 		}
-		return err
 	}
-	return nil
+	if figErr, ok := err.(FigError); ok && asm.len() > 1 {
+		figErr.Cause = asm.describe(figErr.Cause)
+		return figErr
+	}
+	return err
 }
 
-func (fig *Fig) initialize(holder interface{}, assemblingChain *[]string) error {
+func (fig *Fig) initialize(holder interface{}, asm *assembly) error {
 	holderType := reflect.TypeOf(holder)
 	if holderType == nil {
 		return FigError{Cause: "nil cannot be holder", Error_: ErrorCannotBeHolder}
@@ -129,48 +521,405 @@ func (fig *Fig) initialize(holder interface{}, assemblingChain *[]string) error
 			Error_: ErrorCannotBeHolder,
 		}
 	}
-	if err := fig.AssembleRegistered(assemblingChain); err != nil {
+	missing, err := collectMissingValues(nil, fig.assembleRegistered(asm))
+	if err != nil {
+		return err
+	}
+	if missing, err = collectMissingValues(missing, fig.assemble(holder, asm, false)); err != nil {
 		return err
 	}
-	return fig.assemble(holder, assemblingChain, false)
+	return missingValuesError(missing)
+}
+
+// collectMissingValues appends causes of missing required values to the list
+// and returns any other error as is, so all missing values can be reported at once.
+func collectMissingValues(missing []string, err error) ([]string, error) {
+	if figErr, ok := err.(FigError); ok && figErr.Error_ == ErrorRequiredValueMissing {
+		return append(missing, figErr.Cause), nil
+	}
+	return missing, err
+}
+
+func missingValuesError(missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return FigError{Cause: strings.Join(missing, ", "), Error_: ErrorRequiredValueMissing}
+}
+
+// checkUnexportedField returns error if unexported field has `fig` tag that requires injection.
+func checkUnexportedField(tag reflect.StructTag, fieldName string) error {
+	if _, tagged := tag.Lookup(FIG_TAG); !tagged {
+		return nil
+	}
+	if skip, err := getBoolFigTagConfig(tag, SKIP_TAG_KEY); err != nil || skip {
+		return err
+	}
+	return FigError{
+		Cause:  fmt.Sprintf("Unexported field %s can't be injected, remove `fig` tag or use skip configuration", fieldName),
+		Error_: ErrorIncorrectTagConfiguration,
+	}
 }
 
 func (fig *Fig) AssembleRegistered(assemblingChain *[]string) error {
-	for regType, regObject := range fig.registered {
-		if !fig.assembled[regType] {
-			fig.assembled[regType] = true
-			if err := fig.assemble(regObject, assemblingChain, true); err != nil {
-				return err
+	return fig.assembleRegistered(newAssembly(context.Background(), assemblingChain))
+}
+
+func (fig *Fig) assembleRegistered(asm *assembly) error {
+	if len(fig.notAssembled()) == 0 {
+		return nil
+	}
+	defer asm.lockConstruction(fig)()
+
+	var missing []string
+	for _, regType := range fig.notAssembled() {
+		chainLen := asm.len()
+		_, err := fig.resolveComponent(regType, asm)
+		if missing, err = collectMissingValues(missing, err); err != nil {
+			return err
+		}
+		asm.truncate(chainLen)
+	}
+	return missingValuesError(missing)
+}
+
+// notAssembled returns registered types that are not assembled yet, providers and objects that are not
+// singletons are not included because they are constructed only when their result is needed.
+func (fig *Fig) notAssembled() []reflect.Type {
+	fig.mu.RLock()
+	defer fig.mu.RUnlock()
+	var regTypes []reflect.Type
+	for _, regType := range fig.registrationOrder {
+		if _, isProvider := fig.registered[regType].(*provider); !isProvider && fig.scopes[regType] == singletonScope && !fig.assembled[regType] {
+			regTypes = append(regTypes, regType)
+		}
+	}
+	return regTypes
+}
+
+// resolveComponent returns registered object ready for injection. Object is assembled or constructed
+// by provider if it wasn't done yet, constructionMu is acquired for that unless assembly holds it already.
+// Object that is still being assembled is returned only to the assembly that holds constructionMu,
+// this is how references between registered objects are resolved.
+func (fig *Fig) resolveComponent(regType reflect.Type, asm *assembly) (interface{}, error) {
+	regObject, registration, regScope, assembled, ready := fig.componentState(regType)
+	switch regScope {
+	case prototypeScope:
+		// prototype instances are owned by objects they are injected into, so they are not closed by Fig
+		return fig.newInstance(fig, regType, regObject, asm)
+	case requestScope:
+		return fig.resolveRequestScoped(fig, regType, regObject, asm)
+	}
+	constructing := asm.constructs(fig)
+	if ready || constructing && assembled {
+		return regObject, nil
+	}
+
+	if !constructing {
+		defer asm.lockConstruction(fig)()
+		if regObject, registration, _, assembled, _ = fig.componentState(regType); assembled {
+			return regObject, nil
+		}
+	}
+
+	if prov, isProvider := regObject.(*provider); isProvider {
+		return fig.provide(regType, prov, registration, asm)
+	}
+	fig.ifRegistered(regType, registration, func() {
+		fig.assembled[regType] = true
+	})
+	initialized := false
+	defer func() {
+		// object is assembled again by the next initialization, so the error is not hidden
+		if !initialized {
+			fig.ifRegistered(regType, registration, func() {
+				delete(fig.assembled, regType)
+			})
+		}
+	}()
+	var injections []GraphField
+	if err := fig.assembleRecorded(regObject, asm, true, &injections); err != nil {
+		return nil, err
+	}
+	if err := fig.initComponent(regType, regObject, registration, asm); err != nil {
+		return nil, err
+	}
+	initialized = true
+	fig.recordInjections(regType, nil, injections)
+	return regObject, nil
+}
+
+func (fig *Fig) componentState(regType reflect.Type) (regObject interface{}, registration int, regScope scope, assembled, ready bool) {
+	fig.mu.RLock()
+	defer fig.mu.RUnlock()
+	return fig.registered[regType], fig.registrations[regType], fig.scopes[regType], fig.assembled[regType], fig.ready[regType]
+}
+
+// ifRegistered applies change of the state under mu if regType wasn't registered again
+// after the registration that is being assembled.
+func (fig *Fig) ifRegistered(regType reflect.Type, registration int, change func()) {
+	fig.mu.Lock()
+	defer fig.mu.Unlock()
+	if fig.registrations[regType] == registration {
+		change()
+	}
+}
+
+// newInstance calls provider or assembles copy of template registered in owner and initializes the result.
+// Fields and parameters are resolved by fig, that can be different from the owner for request scoped objects.
+func (fig *Fig) newInstance(owner *Fig, regType reflect.Type, regObject interface{}, asm *assembly) (interface{}, error) {
+	var instance interface{}
+	var injections []GraphField
+	prov, isProvider := regObject.(*provider)
+	if isProvider {
+		var err error
+		if instance, injections, err = fig.construct(prov, asm); err != nil {
+			return nil, err
+		}
+	} else {
+		template := reflect.ValueOf(regObject)
+		instanceValue := reflect.New(regType)
+		if regType.Kind() == reflect.Ptr {
+			instanceValue = reflect.New(regType.Elem())
+			template = template.Elem()
+		}
+		instanceValue.Elem().Set(template)
+		if err := fig.assembleRecorded(instanceValue.Interface(), asm, true, &injections); err != nil {
+			return nil, err
+		}
+		if regType.Kind() != reflect.Ptr {
+			instanceValue = instanceValue.Elem()
+		}
+		instance = instanceValue.Interface()
+	}
+	if err := callInit(instance, asm.ctx); err != nil {
+		return nil, err
+	}
+	owner.recordInjections(regType, prov, injections)
+	return instance, nil
+}
+
+// registeredTypes returns snapshot of registered types in order of registration.
+func (fig *Fig) registeredTypes() []reflect.Type {
+	fig.mu.RLock()
+	defer fig.mu.RUnlock()
+	return append([]reflect.Type(nil), fig.registrationOrder...)
+}
+
+func (fig *Fig) initComponent(regType reflect.Type, component interface{}, registration int, asm *assembly) error {
+	if err := callInit(component, asm.ctx); err != nil {
+		return err
+	}
+	fig.mu.Lock()
+	fig.components = append(fig.components, component)
+	if fig.registrations[regType] == registration {
+		fig.ready[regType] = true
+	}
+	fig.mu.Unlock()
+	return nil
+}
+
+func callInit(component interface{}, ctx context.Context) error {
+	var err error
+	switch initializer := component.(type) {
+	case Initializer:
+		err = initializer.Init()
+	case ContextInitializer:
+		err = initializer.Init(ctx)
+	}
+	if err != nil {
+		return FigError{
+			Cause:  fmt.Sprintf("Init of %T failed: %v", component, err),
+			Error_: ErrorInitializationFailed,
+		}
+	}
+	return nil
+}
+
+// assembly is state of a single initialization: chain of what is being assembled
+// and context passed to Init methods. Links of the chain are names of holders, their fields,
+// providers and their parameters, nodes are holders and providers that are used to detect cycles.
+type assembly struct {
+	links *[]string
+	nodes []chainNode
+	ctx   context.Context
+}
+
+// chainNode is a struct or constructor function, link is index of its name in the links.
+type chainNode struct {
+	nodeType reflect.Type
+	link     int
+}
+
+func newAssembly(ctx context.Context, assemblingChain *[]string) *assembly {
+	return &assembly{links: assemblingChain, ctx: ctx}
+}
+
+func (asm *assembly) len() int {
+	return len(*asm.links)
+}
+
+func (asm *assembly) push(link string) {
+	*asm.links = append(*asm.links, link)
+}
+
+// pushNode returns error if node is already being assembled up the chain.
+// Struct and reference to it are the same node. References between registered objects
+// are not cycles, because they are assembled only once.
+func (asm *assembly) pushNode(nodeType reflect.Type) error {
+	for nodeIndex, node := range asm.nodes {
+		if node.nodeType == nodeType {
+			var cycle []string
+			for _, cycleNode := range asm.nodes[nodeIndex:] {
+				cycle = append(cycle, cycleNode.nodeType.String())
+			}
+			return FigError{
+				Cause:  "Dependency cycle: " + strings.Join(append(cycle, nodeType.String()), " -> "),
+				Error_: ErrorDependencyCycle,
 			}
 		}
 	}
+	asm.nodes = append(asm.nodes, chainNode{nodeType: nodeType, link: asm.len()})
+	asm.push(nodeType.String())
 	return nil
 }
 
-func getFigTagConfig(tag reflect.StructTag, key string) (string, bool, error) {
-	if figTag, ok := tag.Lookup(FIG_TAG); ok {
-		return getConfigValueForKey(figTag, key)
-	} else {
-		return "", false, nil
+// truncate removes links and nodes added after the chain had the length.
+func (asm *assembly) truncate(length int) {
+	*asm.links = (*asm.links)[:length]
+	for len(asm.nodes) > 0 && asm.nodes[len(asm.nodes)-1].link >= length {
+		asm.nodes = asm.nodes[:len(asm.nodes)-1]
+	}
+}
+
+// describe prepends links of the chain to the cause of the error.
+func (asm *assembly) describe(cause string) string {
+	return strings.Join(*asm.links, " -> ") + "=> " + cause
+}
+
+type constructionKey struct {
+	fig *Fig
+}
+
+// construction is put into context of assembly while it holds constructionMu of the injector,
+// it is valid only until the lock is released.
+type construction struct {
+	released int32
+}
+
+// constructs reports if constructionMu of fig is held by this assembly
+// or by initialization that passed its context to Init method.
+func (asm *assembly) constructs(fig *Fig) bool {
+	held, ok := asm.ctx.Value(constructionKey{fig}).(*construction)
+	return ok && atomic.LoadInt32(&held.released) == 0
+}
+
+// lockConstruction acquires constructionMu of fig unless assembly holds it already.
+// Returned function releases the lock acquired by this call.
+func (asm *assembly) lockConstruction(fig *Fig) func() {
+	if asm.constructs(fig) {
+		return func() {}
+	}
+	fig.constructionMu.Lock()
+	held, unlocked := new(construction), asm.ctx
+	asm.ctx = context.WithValue(unlocked, constructionKey{fig}, held)
+	return func() {
+		atomic.StoreInt32(&held.released, 1)
+		asm.ctx = unlocked
+		fig.constructionMu.Unlock()
 	}
 }
 
-func getConfigValueForKey(conf string, key string) (string, bool, error) {
-	confHolder := key + "["
-	keyStart := strings.Index(conf, confHolder)
-	if keyStart < 0 {
-		return "", false, nil
+// Close calls Close method of all initialized objects that implement Closer
+// in reverse order of their initialization, so dependencies are closed after
+// objects that depend on them. All errors are collected into single one.
+func (fig *Fig) Close() error {
+	fig.mu.Lock()
+	components := fig.components
+	fig.components = nil
+	fig.mu.Unlock()
+	return closeAll(components)
+}
+
+func closeAll(components []interface{}) error {
+	var failures []string
+	for componentIndex := len(components) - 1; componentIndex >= 0; componentIndex-- {
+		if closer, ok := components[componentIndex].(Closer); ok {
+			if err := closer.Close(); err != nil {
+				failures = append(failures, fmt.Sprintf("%T: %v", closer, err))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return FigError{Cause: strings.Join(failures, ", "), Error_: ErrorCloseFailed}
 	}
+	return nil
+}
 
-	valStart := keyStart + len(confHolder)
-	valEnd := strings.Index(conf[valStart:], "]")
-	if valEnd <= 0 {
-		return "", false, FigError{
-			Cause:  "Invalid configuration in: " + conf + " for configuration: " + key,
+func getFigTagConfig(tag reflect.StructTag, key string) (string, bool, error) {
+	conf, err := parseFigTag(tag)
+	if err != nil {
+		return "", false, err
+	}
+	value, found := conf.values[key]
+	return value, found, nil
+}
+
+// hasFigTagFlag reports if configuration is defined without value.
+func hasFigTagFlag(tag reflect.StructTag, key string) bool {
+	conf, err := parseFigTag(tag)
+	return err == nil && conf.flags[key]
+}
+
+func getBoolFigTagConfig(tag reflect.StructTag, key string) (bool, error) {
+	if hasFigTagFlag(tag, key) {
+		return true, nil
+	}
+	confValue, found, err := getFigTagConfig(tag, key)
+	if err != nil || !found {
+		return false, err
+	}
+	switch confValue {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, FigError{
+			Cause:  "Incorrectly defined configuration `" + key + "` of `fig` tag. Supported values: true | false. Got: " + confValue,
 			Error_: ErrorIncorrectTagConfiguration,
 		}
 	}
-	return string(conf[valStart : valStart+valEnd]), true, nil
+}
+
+// setDefaultValue is used when value for the field was not found by key defined in missingConf.
+// It sets value from `default` configuration or reports missing value if field is `required`.
+// Returns false if there is neither `default` nor `required` configuration.
+func setDefaultValue(tag reflect.StructTag, field reflect.Value, fieldName string, missingConf string) (bool, error) {
+	if defaultValue, found, err := getFigTagConfig(tag, DEFAULT_TAG_KEY); err != nil {
+		return false, err
+	} else if found {
+		if err := setFromString(field, defaultValue); err != nil {
+			return true, FigError{
+				Cause: fmt.Sprintf("Default value %s can't be assigned to field %s of type %s: %v",
+					defaultValue, fieldName, field.Type(), err),
+				Error_: ErrorIncorrectTagConfiguration,
+			}
+		}
+		return true, nil
+	}
+
+	required, err := getBoolFigTagConfig(tag, REQUIRED_TAG_KEY)
+	if err != nil {
+		return false, err
+	}
+	if required {
+		return true, FigError{
+			Cause:  fmt.Sprintf("%s for field %s", missingConf, fieldName),
+			Error_: ErrorRequiredValueMissing,
+		}
+	}
+	return false, nil
 }
 
 func setByImplConf(canBeSet []interface{}, elementField reflect.Value, implFigConf string) error {
@@ -200,23 +949,30 @@ func setByQualConf(canBeSet []interface{}, elementField reflect.Value, qualFigCo
 	}
 }
 
-func (fig *Fig) setFoundImpl(canBeSet []interface{}, elementField reflect.Value, tag reflect.StructTag, assemblingChain *[]string) error {
+func (fig *Fig) setFoundImpl(canBeSet []interface{}, elementField reflect.Value, tag reflect.StructTag, asm *assembly, recursive bool) (InjectionReason, error) {
 	switch {
 	case len(canBeSet) > 1:
 		if implFigConf, found, err := getFigTagConfig(tag, IMPL_TAG_KEY); err != nil {
-			return err
+			return "", err
 		} else if found {
-			return setByImplConf(canBeSet, elementField, implFigConf)
+			return ReasonImpl, setByImplConf(canBeSet, elementField, implFigConf)
 		} else if qualFigConf, found, err := getFigTagConfig(tag, QUAL_TAG_KEY); err != nil {
-			return err
+			return "", err
 		} else if found {
-			return setByQualConf(canBeSet, elementField, qualFigConf)
+			return ReasonQual, setByQualConf(canBeSet, elementField, qualFigConf)
+		} else if primaries := primaryOf(canBeSet); len(primaries) == 1 {
+			elementField.Addr().Elem().Set(reflect.ValueOf(primaries[0]))
+			return ReasonPrimary, nil
 		} else {
 			mes := "Can't chose implementation for " + elementField.String() + ":\n"
+			if len(primaries) > 1 {
+				mes = "Multiple primary implementations for " + elementField.String() + ":\n"
+				canBeSet = primaries
+			}
 			for _, canBe := range canBeSet {
 				mes += fmt.Sprintf("\t%T\n", canBe)
 			}
-			return FigError{Cause: mes, Error_: ErrorCannotDecideImplementation}
+			return "", FigError{Cause: mes, Error_: ErrorCannotDecideImplementation}
 		}
 
 	case len(canBeSet) < 1:
@@ -229,11 +985,12 @@ func (fig *Fig) setFoundImpl(canBeSet []interface{}, elementField reflect.Value,
 				}
 				elementField.Set(reflect.New(elementField.Type()).Elem())
 			}
-			if err := fig.initialize(elementField.Addr().Interface(), assemblingChain); err != nil {
-				return err
+			if err := fig.assemble(elementField.Addr().Interface(), asm, recursive); err != nil {
+				return "", err
 			}
+			return ReasonAutoCreated, nil
 		default:
-			return FigError{Cause: "No implementation found for " + elementField.String(), Error_: ErrorCannotDecideImplementation}
+			return "", FigError{Cause: "No implementation found for " + elementField.String(), Error_: ErrorCannotDecideImplementation}
 		}
 		//switch elementField.Kind() {
 		//case reflect.Struct:
@@ -255,8 +1012,18 @@ func (fig *Fig) setFoundImpl(canBeSet []interface{}, elementField reflect.Value,
 		//}
 	default:
 		elementField.Addr().Elem().Set(reflect.ValueOf(canBeSet[0]))
+		return ReasonSoleCandidate, nil
 	}
-	return nil
+}
+
+func primaryOf(canBeSet []interface{}) []interface{} {
+	var primaries []interface{}
+	for _, canBe := range canBeSet {
+		if primary, ok := canBe.(Primary); ok && primary.Primary() {
+			primaries = append(primaries, canBe)
+		}
+	}
+	return primaries
 }
 
 func checkQualifier(canBe interface{}, qualFigConf string) bool {
@@ -298,7 +1065,8 @@ type InjectStepValueSetup struct {
 	holderElementField reflect.Value
 	tag                reflect.StructTag
 	recursive          bool
-	assemblingChain    *[]string
+	assembly           *assembly
+	reason             InjectionReason
 }
 
 func NewValueSetup(fig *Fig,
@@ -306,36 +1074,159 @@ func NewValueSetup(fig *Fig,
 	holderElementField reflect.Value,
 	recursive bool,
 	assemblingChain *[]string) *InjectStepValueSetup {
+	return newValueSetup(fig, tag, holderElementField, recursive, newAssembly(context.Background(), assemblingChain))
+}
+
+func newValueSetup(fig *Fig,
+	tag reflect.StructTag,
+	holderElementField reflect.Value,
+	recursive bool,
+	asm *assembly) *InjectStepValueSetup {
 	return &InjectStepValueSetup{
 		fig:                fig,
 		holderElementField: holderElementField,
 		recursive:          recursive,
 		tag:                tag,
-		assemblingChain:    assemblingChain,
+		assembly:           asm,
 	}
 }
 
-func (valueSetup *InjectStepValueSetup) injectIf(condition func(l, r reflect.Type) bool) error {
+// collectCandidates returns registered objects that satisfy condition. Objects registered in the parents
+// are used only if there are no candidates registered in the injector itself, but if all is true candidates
+// from all parents are returned except those which types are registered in children.
+func (valueSetup *InjectStepValueSetup) collectCandidates(targetType reflect.Type, condition func(l, r reflect.Type) bool, all bool) ([]interface{}, error) {
 	var canBeSet []interface{}
-	for registeredType, injectableObj := range valueSetup.fig.registered {
-		if condition(registeredType, valueSetup.holderElementField.Type()) {
-			if valueSetup.recursive && !valueSetup.fig.assembled[registeredType] {
-				if err := valueSetup.fig.assemble(injectableObj, valueSetup.assemblingChain, valueSetup.recursive); err != nil {
-					return err
-				}
+	overridden := make(map[reflect.Type]bool)
+	for injector := valueSetup.fig; injector != nil; injector = injector.parent {
+		for _, registeredType := range injector.registeredTypes() {
+			if overridden[registeredType] || !condition(registeredType, targetType) || !injector.boundTo(registeredType, targetType) {
+				continue
+			}
+			overridden[registeredType] = true
+			injectableObj, err := valueSetup.resolveCandidate(injector, registeredType)
+			if err != nil {
+				return nil, err
 			}
 			canBeSet = append(canBeSet, injectableObj)
 		}
+		if len(canBeSet) > 0 && !all {
+			break
+		}
+	}
+	return canBeSet, nil
+}
+
+// resolveCandidate resolves object registered in the injector. Request scoped objects
+// are always resolved by the injector that initializes the holder, because only it has the context.
+func (valueSetup *InjectStepValueSetup) resolveCandidate(injector *Fig, regType reflect.Type) (interface{}, error) {
+	injector.mu.RLock()
+	regObject, regScope := injector.registered[regType], injector.scopes[regType]
+	injector.mu.RUnlock()
+	if regScope == requestScope {
+		return valueSetup.fig.resolveRequestScoped(injector, regType, regObject, valueSetup.assembly)
+	}
+	return injector.resolveComponent(regType, valueSetup.assembly)
+}
+
+func (valueSetup *InjectStepValueSetup) injectIf(condition func(l, r reflect.Type) bool) error {
+	canBeSet, err := valueSetup.collectCandidates(valueSetup.holderElementField.Type(), condition, false)
+	if err != nil {
+		return err
+	}
+	valueSetup.reason, err = valueSetup.fig.setFoundImpl(canBeSet, valueSetup.holderElementField, valueSetup.tag, valueSetup.assembly, valueSetup.recursive)
+	return err
+}
+
+func elementCondition(elementType reflect.Type) func(l, r reflect.Type) bool {
+	switch elementType.Kind() {
+	case reflect.Interface:
+		return func(l, r reflect.Type) bool {
+			return l.Implements(r)
+		}
+	case reflect.Ptr, reflect.Struct:
+		return func(l, r reflect.Type) bool {
+			return l.AssignableTo(r)
+		}
+	default:
+		return nil
+	}
+}
+
+// injectAll sets all registered implementations of slice element type into the slice
+// in order of their registration.
+func (valueSetup *InjectStepValueSetup) injectAll() error {
+	sliceType := valueSetup.holderElementField.Type()
+	condition := elementCondition(sliceType.Elem())
+	if condition == nil {
+		return FigError{
+			Cause:  "Configuration `all` can be used only for slices of interfaces, structs or references to structs: " + sliceType.String(),
+			Error_: ErrorIncorrectTagConfiguration,
+		}
+	}
+
+	canBeSet, err := valueSetup.collectCandidates(sliceType.Elem(), condition, true)
+	if err != nil {
+		return err
+	}
+	all := reflect.MakeSlice(sliceType, len(canBeSet), len(canBeSet))
+	for canBeIndex, canBe := range canBeSet {
+		all.Index(canBeIndex).Set(reflect.ValueOf(canBe))
+	}
+	valueSetup.holderElementField.Set(all)
+	valueSetup.reason = ReasonAll
+	return nil
+}
+
+// injectQualified sets all registered implementations of map value type into the map.
+// Key of implementation is a value returned by `Qualify` method or full name of its type.
+func (valueSetup *InjectStepValueSetup) injectQualified() error {
+	mapType := valueSetup.holderElementField.Type()
+	condition := elementCondition(mapType.Elem())
+	if condition == nil || mapType.Key().Kind() != reflect.String {
+		return FigError{
+			Cause:  "Configuration `qualified` can be used only for maps with string keys and values of interfaces, structs or references to structs: " + mapType.String(),
+			Error_: ErrorIncorrectTagConfiguration,
+		}
 	}
-	if err := valueSetup.fig.setFoundImpl(canBeSet, valueSetup.holderElementField, valueSetup.tag, valueSetup.assemblingChain); err != nil {
+
+	canBeSet, err := valueSetup.collectCandidates(mapType.Elem(), condition, true)
+	if err != nil {
 		return err
 	}
+	qualified := reflect.MakeMap(mapType)
+	for _, canBe := range canBeSet {
+		key := reflect.ValueOf(qualifierOf(canBe)).Convert(mapType.Key())
+		if existing := qualified.MapIndex(key); existing.IsValid() {
+			return FigError{
+				Cause:  fmt.Sprintf("Implementations %T and %T have same qualifier: %s", existing.Interface(), canBe, key),
+				Error_: ErrorDuplicateQualifier,
+			}
+		}
+		qualified.SetMapIndex(key, reflect.ValueOf(canBe))
+	}
+	valueSetup.holderElementField.Set(qualified)
+	valueSetup.reason = ReasonQualified
 	return nil
 }
 
+func qualifierOf(canBe interface{}) string {
+	if qualifier, ok := canBe.(Qualifier); ok {
+		return qualifier.Qualify()
+	}
+	if fullName := getFullName(canBe); fullName != "" {
+		return fullName
+	}
+	return reflect.TypeOf(canBe).String()
+}
+
 func (valueSetup *InjectStepValueSetup) Do() error {
 	switch valueSetup.holderElementField.Kind() {
 	case reflect.Interface:
+		if valueSetup.holderElementField.Type() == contextType {
+			valueSetup.holderElementField.Set(reflect.ValueOf(valueSetup.assembly.ctx))
+			valueSetup.reason = ReasonContext
+			return nil
+		}
 		if err := valueSetup.injectIf(func(l, r reflect.Type) bool {
 			return l.Implements(r)
 		}); err != nil {
@@ -343,6 +1234,11 @@ func (valueSetup *InjectStepValueSetup) Do() error {
 		}
 
 	case reflect.Ptr, reflect.Struct:
+		if lazy, ok := lazyOf(valueSetup.holderElementField); ok {
+			lazy.bind(valueSetup.fig, valueSetup.tag, valueSetup.assembly.ctx)
+			valueSetup.reason = ReasonLazy
+			return nil
+		}
 		if err := valueSetup.injectIf(func(l, r reflect.Type) bool {
 			return l.AssignableTo(r)
 		}); err != nil {
@@ -350,14 +1246,12 @@ func (valueSetup *InjectStepValueSetup) Do() error {
 		}
 
 	case reflect.String:
-		if envKey, found, err := getFigTagConfig(valueSetup.tag, ENV_TAG_KEY); err != nil {
-			return err
-		} else if found {
-			envVal := os.Getenv(envKey)
-			valueSetup.holderElementField.Addr().Elem().Set(reflect.ValueOf(envVal))
-		}
 
 	case reflect.Map:
+		if hasFigTagFlag(valueSetup.tag, QUALIFIED_TAG_KEY) {
+			return valueSetup.injectQualified()
+		}
+
 		valueSetup.holderElementField.Set(
 			reflect.MakeMap(
 				reflect.MapOf(
@@ -366,6 +1260,7 @@ func (valueSetup *InjectStepValueSetup) Do() error {
 				),
 			),
 		)
+		valueSetup.reason = ReasonCollection
 
 	case reflect.Chan:
 		val, found, err := getFigTagConfig(valueSetup.tag, SIZE_TAG_KEY)
@@ -390,8 +1285,13 @@ func (valueSetup *InjectStepValueSetup) Do() error {
 				size,
 			),
 		)
+		valueSetup.reason = ReasonCollection
 
 	case reflect.Slice:
+		if hasFigTagFlag(valueSetup.tag, ALL_TAG_KEY) {
+			return valueSetup.injectAll()
+		}
+
 		size := 0
 		val, found, err := getFigTagConfig(valueSetup.tag, SIZE_TAG_KEY)
 		if err != nil {
@@ -435,6 +1335,7 @@ func (valueSetup *InjectStepValueSetup) Do() error {
 				capacity,
 			),
 		)
+		valueSetup.reason = ReasonCollection
 	//case
 	//	reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 	//	reflect.Float32, reflect.Float64,
@@ -456,20 +1357,41 @@ type InjectStepRegisteredValueSetup struct {
 	fig                *Fig
 	tag                reflect.StructTag
 	holderElementField reflect.Value
+	fieldName          string
 	skip               bool
+	reason             InjectionReason
+	source             string
 }
 
-func NewRegisteredValueSetup(fig *Fig, tag reflect.StructTag, holderElementField reflect.Value) *InjectStepRegisteredValueSetup {
-	return &InjectStepRegisteredValueSetup{fig: fig, tag: tag, holderElementField: holderElementField}
+func NewRegisteredValueSetup(fig *Fig, tag reflect.StructTag, holderElementField reflect.Value, fieldName string) *InjectStepRegisteredValueSetup {
+	return &InjectStepRegisteredValueSetup{fig: fig, tag: tag, holderElementField: holderElementField, fieldName: fieldName}
 }
 
 func (registeredValue *InjectStepRegisteredValueSetup) Do() error {
+	// registered value is set into Lazy when it is resolved
+	if _, isLazy := lazyOf(registeredValue.holderElementField); isLazy {
+		return nil
+	}
 	if regKey, found, err := getFigTagConfig(registeredValue.tag, REG_TAG_KEY); err != nil {
 		return err
 	} else if found {
-		if regValue, found := registeredValue.fig.registeredValues[regKey]; found {
-			registeredValue.holderElementField.Addr().Elem().Set(reflect.ValueOf(regValue))
+		if regValue, found := registeredValue.fig.registeredValue(regKey); found {
+			registeredValue.skip = true
+			if err := setFromValue(registeredValue.holderElementField, regValue); err != nil {
+				return FigError{
+					Cause: fmt.Sprintf("Registered value %s of type %T can't be assigned to field %s of type %s: %v",
+						regKey, regValue, registeredValue.fieldName, registeredValue.holderElementField.Type(), err),
+					Error_: ErrorIncorrectTagConfiguration,
+				}
+			}
+			registeredValue.reason, registeredValue.source = ReasonRegisteredValue, regKey
+		} else if defaultSet, err := setDefaultValue(registeredValue.tag, registeredValue.holderElementField,
+			registeredValue.fieldName, REG_TAG_KEY+"["+regKey+"]"); defaultSet || err != nil {
 			registeredValue.skip = true
+			if err == nil {
+				registeredValue.reason, registeredValue.source = ReasonDefault, regKey
+			}
+			return err
 		} else {
 			return FigError{
 				Cause:  fmt.Sprintf("Implementation was not found for: %s", registeredValue.holderElementField),
@@ -484,6 +1406,53 @@ func (registeredValue *InjectStepRegisteredValueSetup) Break() bool {
 	return registeredValue.skip
 }
 
+type InjectStepEnvValueSetup struct {
+	fig                *Fig
+	tag                reflect.StructTag
+	holderElementField reflect.Value
+	fieldName          string
+	skip               bool
+	reason             InjectionReason
+	source             string
+}
+
+func NewEnvValueSetup(fig *Fig, tag reflect.StructTag, holderElementField reflect.Value, fieldName string) *InjectStepEnvValueSetup {
+	return &InjectStepEnvValueSetup{fig: fig, tag: tag, holderElementField: holderElementField, fieldName: fieldName}
+}
+
+func (envValue *InjectStepEnvValueSetup) Do() error {
+	// environment variable is set into Lazy when it is resolved
+	if _, isLazy := lazyOf(envValue.holderElementField); isLazy {
+		return nil
+	}
+	envKey, found, err := getFigTagConfig(envValue.tag, ENV_TAG_KEY)
+	if err != nil || !found {
+		return err
+	}
+	envValue.skip = true
+	envVal, found := envValue.fig.lookupEnv(envKey)
+	if !found {
+		defaultSet, err := setDefaultValue(envValue.tag, envValue.holderElementField, envValue.fieldName, ENV_TAG_KEY+"["+envKey+"]")
+		if defaultSet && err == nil {
+			envValue.reason, envValue.source = ReasonDefault, envKey
+		}
+		return err
+	}
+	envValue.reason, envValue.source = ReasonEnv, envKey
+	if err := setFromValue(envValue.holderElementField, envVal); err != nil {
+		return FigError{
+			Cause: fmt.Sprintf("Environment variable %s can't be assigned to field %s of type %s: %v",
+				envKey, envValue.fieldName, envValue.holderElementField.Type(), err),
+			Error_: ErrorIncorrectTagConfiguration,
+		}
+	}
+	return nil
+}
+
+func (envValue *InjectStepEnvValueSetup) Break() bool {
+	return envValue.skip
+}
+
 type InjectStepFigTagRequiredCheck struct {
 	fig  *Fig
 	tag  reflect.StructTag
@@ -518,25 +1487,40 @@ func NewSkipCheck(tag reflect.StructTag) *InjectStepSkipCheck {
 }
 
 func (skipVerify *InjectStepSkipCheck) Do() error {
-	if skipConfValue, found, err := getFigTagConfig(skipVerify.tag, SKIP_TAG_KEY); err != nil {
-		return err
-	} else if found {
-		if skipConfValue == "true" {
-			skipVerify.skip = true
-		} else if skipConfValue != "false" {
-			return FigError{
-				Cause:  "Incorrectly defined configuration `skip` of `fig` tag. Supported values: true | false. Got: " + skipConfValue,
-				Error_: ErrorIncorrectTagConfiguration,
-			}
-		}
-	}
-	return nil
+	skip, err := getBoolFigTagConfig(skipVerify.tag, SKIP_TAG_KEY)
+	skipVerify.skip = skip
+	return err
 }
 
 func (skipVerify *InjectStepSkipCheck) Break() bool {
 	return skipVerify.skip
 }
 
+type InjectStepSettableCheck struct {
+	holderElementField reflect.Value
+	fieldName          string
+}
+
+func NewSettableCheck(holderElementField reflect.Value, fieldName string) *InjectStepSettableCheck {
+	return &InjectStepSettableCheck{holderElementField: holderElementField, fieldName: fieldName}
+}
+
+// Do returns error instead of panic of reflect package if the field can't be set,
+// e.g. it is a field of object registered by value instead of reference to it.
+func (settableCheck *InjectStepSettableCheck) Do() error {
+	if settableCheck.holderElementField.CanSet() {
+		return nil
+	}
+	return FigError{
+		Cause:  fmt.Sprintf("Field %s can't be set, register reference to the object instead of its value", settableCheck.fieldName),
+		Error_: ErrorCannotBeInjected,
+	}
+}
+
+func (settableCheck *InjectStepSettableCheck) Break() bool {
+	return false
+}
+
 type StepMachine struct {
 	steps []InjectStep
 }
@@ -562,30 +1546,76 @@ func (sm *StepMachine) Do() error {
 	return nil
 }
 
-func (fig *Fig) assemble(holder interface{}, assemblingChain *[]string, recursive bool) error {
+func (fig *Fig) assemble(holder interface{}, asm *assembly, recursive bool) error {
+	return fig.assembleRecorded(holder, asm, recursive, nil)
+}
+
+// assembleRecorded assembles holder and appends description of every injected field to injections if it is not nil.
+func (fig *Fig) assembleRecorded(holder interface{}, asm *assembly, recursive bool, injections *[]GraphField) error {
 	holderElement := reflect.ValueOf(holder)
 	if holderElement.Kind() == reflect.Ptr {
 		holderElement = holderElement.Elem()
 	}
 	holderElementType := holderElement.Type()
-	*assemblingChain = append(*assemblingChain, holderElementType.String())
+	if err := asm.pushNode(holderElementType); err != nil {
+		return err
+	}
 	numFields := holderElement.NumField()
 
+	var missing []string
 	for fieldIndex := 0; fieldIndex < numFields; fieldIndex++ {
 		holderElementField := holderElement.Field(fieldIndex)
 		tag := holderElementType.Field(fieldIndex).Tag
+		fieldName := holderElementType.Field(fieldIndex).Name
+		if holderElementType.Name() != "" {
+			fieldName = holderElementType.Name() + "." + fieldName
+		}
+		if !holderElementType.Field(fieldIndex).IsExported() {
+			// unexported fields can't be set, so they are injected only by mistake
+			if err := checkUnexportedField(tag, fieldName); err != nil {
+				return err
+			}
+			continue
+		}
 		holderElementFieldType := holderElementField.Type()
-		*assemblingChain = append(*assemblingChain, holderElementFieldType.String())
-		if err := NewStepMachine().Add(
+		chainLen := asm.len()
+		asm.push(holderElementFieldType.String())
+		tagHandlerSetup := NewTagHandlerSetup(fig, tag, holderElementField, fieldName)
+		registeredValueSetup := NewRegisteredValueSetup(fig, tag, holderElementField, fieldName)
+		flagValueSetup := NewFlagValueSetup(fig, tag, holderElementField, fieldName)
+		envValueSetup := NewEnvValueSetup(fig, tag, holderElementField, fieldName)
+		valueSetup := newValueSetup(fig, tag, holderElementField, recursive, asm)
+		err := NewStepMachine().Add(
+			NewTagValidation(fig, tag, fieldName),
 			NewFigTagRequiredCheck(fig, tag),
 			NewSkipCheck(tag),
-			NewRegisteredValueSetup(fig, tag, holderElementField),
-			NewValueSetup(fig, tag, holderElementField, recursive, assemblingChain),
-		).Do(); err != nil {
+			NewSettableCheck(holderElementField, fieldName),
+			tagHandlerSetup,
+			registeredValueSetup,
+			flagValueSetup,
+			envValueSetup,
+			valueSetup,
+		).Do()
+		if missing, err = collectMissingValues(missing, err); err != nil {
 			return err
 		}
-		*assemblingChain = (*assemblingChain)[:len(*assemblingChain)-1]
+		if injections != nil {
+			name := holderElementType.Field(fieldIndex).Name
+			switch {
+			case tagHandlerSetup.reason != "":
+				*injections = append(*injections, describeInjection(name, holderElementField, tagHandlerSetup.reason, tagHandlerSetup.source))
+			case registeredValueSetup.reason != "":
+				*injections = append(*injections, describeInjection(name, holderElementField, registeredValueSetup.reason, registeredValueSetup.source))
+			case flagValueSetup.reason != "":
+				*injections = append(*injections, describeInjection(name, holderElementField, flagValueSetup.reason, flagValueSetup.source))
+			case envValueSetup.reason != "":
+				*injections = append(*injections, describeInjection(name, holderElementField, envValueSetup.reason, envValueSetup.source))
+			case valueSetup.reason != "":
+				*injections = append(*injections, describeInjection(name, holderElementField, valueSetup.reason, ""))
+			}
+		}
+		asm.truncate(chainLen)
 	}
-	*assemblingChain = (*assemblingChain)[:len(*assemblingChain)-1]
-	return nil
+	asm.truncate(asm.len() - 1)
+	return missingValuesError(missing)
 }
@@ -1,9 +1,15 @@
 package fig
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pavelmemory/fig/examples/justpackage/otherrepos"
 	"github.com/pavelmemory/fig/examples/justpackage/repos"
@@ -35,7 +41,7 @@ func TestFig_InitializeNil(t *testing.T) {
 	ExpectError(err, t, nil, ErrorCannotBeHolder)
 }
 
-func Example_InitializeStructWithInterfaces() {
+func Example_initializeStructWithInterfaces() {
 	injector := New(false)
 	FatalIfError(func() error {
 		return injector.Register(
@@ -66,7 +72,7 @@ func Example_InitializeStructWithInterfaces() {
 	// remove 2 order from memory
 }
 
-func Example_InitializeStructWithMultipleInterfaceImplementations() {
+func Example_initializeStructWithMultipleInterfaceImplementations() {
 	injector := New(false)
 	FatalIfError(func() error {
 		return injector.Register(
@@ -109,7 +115,7 @@ func TestInitializeStructWithValue(t *testing.T) {
 	}
 }
 
-func Example_InitializeStructWithReference() {
+func Example_initializeStructWithReference() {
 	injector := New(false)
 
 	holder := &struct {
@@ -141,7 +147,7 @@ func TestInitialize_InterfaceWithMultipleImplementationsWithSameStructNameWithou
 	ExpectError(err, t, holder, ErrorCannotDecideImplementation)
 }
 
-func Example_InitializeInterfaceWithMultipleImplementationsWithSameStructNameWithExplicitDefinition() {
+func Example_initializeInterfaceWithMultipleImplementationsWithSameStructNameWithExplicitDefinition() {
 	injector := New(false)
 	FatalIfError(func() error {
 		return injector.Register(
@@ -162,7 +168,7 @@ func Example_InitializeInterfaceWithMultipleImplementationsWithSameStructNameWit
 	// save user IVAN to memory
 }
 
-func Example_InitializeInnerFieldsShouldBeInjectedAutomaticallyIfRegistered() {
+func Example_initializeInnerFieldsShouldBeInjectedAutomaticallyIfRegistered() {
 	injector := New(false)
 	FatalIfError(func() error {
 		return injector.Register(
@@ -204,7 +210,7 @@ func TestInitialize_EnvVar(t *testing.T) {
 	}
 }
 
-func Example_InitializeExplicitImplementationSpecificationSkippedIfSingleImplementationRegistered() {
+func Example_initializeExplicitImplementationSpecificationSkippedIfSingleImplementationRegistered() {
 	injector := New(false)
 	FatalIfError(func() error {
 		return injector.Register(&otherrepos.FileUserRepo{})
@@ -299,7 +305,7 @@ func TestInitialize_RegisterValueNotFound(t *testing.T) {
 	ExpectError(err, t, nil, ErrorCannotDecideImplementation)
 }
 
-func Example_InitializeOnlyWithFigTag() {
+func Example_initializeOnlyWithFigTag() {
 	injector := New(true)
 
 	FatalIfError(func() error {
@@ -952,3 +958,1560 @@ func TestInitialize_FunctionsNotSupported(t *testing.T) {
 	err := injector.Initialize(holder)
 	ExpectError(err, t, holder, ErrorCannotBeHolder)
 }
+
+type providedConfig struct {
+	DSN string
+}
+
+type providedStore struct {
+	Config *providedConfig
+}
+
+func (ps *providedStore) Find(name string) {
+	fmt.Println("find user", name, "in", ps.Config.DSN)
+}
+
+func (ps *providedStore) Save(name string) {
+	fmt.Println("save user", name, "to", ps.Config.DSN)
+}
+
+func TestProvide(t *testing.T) {
+	injector := New(false)
+	calls := 0
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "mem://"})
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func(cfg *providedConfig) (*providedStore, error) {
+			calls++
+			return &providedStore{Config: cfg}, nil
+		})
+	})
+
+	holder := &struct {
+		Store    *providedStore
+		UserRepo repos.UserRepo
+	}{}
+
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	if holder.Store == nil || holder.Store.Config.DSN != "mem://" {
+		t.Fatal("Provided value was not injected")
+	}
+	if holder.UserRepo != holder.Store {
+		t.Error("Provided value must be injected as implementation of interface")
+	}
+	if calls != 1 {
+		t.Errorf("Provider must be called once, but called %d times", calls)
+	}
+}
+
+func TestProvide_Lazy(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() *providedStore {
+			t.Error("Provider must not be called if value is not needed")
+			return new(providedStore)
+		})
+	})
+
+	holder := &struct {
+		OrderRepo *repos.MemOrderRepo
+	}{}
+
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+}
+
+func TestProvide_InterfaceResult(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() repos.UserRepo {
+			return &repos.MemUserRepo{Prefix: "provided"}
+		})
+	})
+
+	holder := &struct {
+		repos.UserRepo
+	}{}
+
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	if memUserRepo, ok := holder.UserRepo.(*repos.MemUserRepo); !ok || memUserRepo.Prefix != "provided" {
+		t.Errorf("Unexpected value injected: %#v", holder.UserRepo)
+	}
+}
+
+func TestProvide_ErrorFromProvider(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() (*providedStore, error) {
+			return nil, errors.New("connection refused")
+		})
+	})
+
+	holder := &struct {
+		Store *providedStore
+	}{}
+
+	err := injector.Initialize(holder)
+	ExpectError(err, t, holder, ErrorProviderFailed)
+	if !strings.Contains(err.Error(), "*fig.providedStore -> func() (*fig.providedStore, error)") {
+		t.Errorf("Assembling chain is not part of error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Provider error is not part of error: %v", err)
+	}
+}
+
+func TestProvide_NilResult(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() *providedStore {
+			return nil
+		})
+	})
+
+	holder := &struct {
+		Store *providedStore
+	}{}
+
+	err := injector.Initialize(holder)
+	ExpectError(err, t, holder, ErrorProviderFailed)
+}
+
+func TestProvide_Errors(t *testing.T) {
+	injector := New(false)
+	for _, cannotBeProvided := range []interface{}{
+		nil,
+		100,
+		new(providedStore),
+		func() {},
+		func() int { return 1 },
+		func() (*providedStore, string) { return nil, "" },
+		func() (*providedStore, error, error) { return nil, nil, nil },
+		func(...string) *providedStore { return nil },
+	} {
+		err := injector.Provide(cannotBeProvided)
+		ExpectError(err, t, cannotBeProvided, ErrorCannotBeRegistered)
+	}
+}
+
+type textUnmarshalerValue struct {
+	value string
+}
+
+func (tuv *textUnmarshalerValue) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty value")
+	}
+	tuv.value = "unmarshaled:" + string(text)
+	return nil
+}
+
+func TestInitialize_EnvVarTyped(t *testing.T) {
+	envs := map[string]string{
+		"FIG_INT":      "-42",
+		"FIG_UINT8":    "255",
+		"FIG_BOOL":     "true",
+		"FIG_FLOAT":    "0.25",
+		"FIG_DURATION": "1m30s",
+		"FIG_URL":      "postgres://localhost:5432/db",
+		"FIG_TEXT":     "text",
+	}
+	for key, value := range envs {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	holder := &struct {
+		Int           int                   `fig:"env[FIG_INT]"`
+		Uint8         uint8                 `fig:"env[FIG_UINT8]"`
+		Bool          bool                  `fig:"env[FIG_BOOL]"`
+		Float         float64               `fig:"env[FIG_FLOAT]"`
+		IntRef        *int                  `fig:"env[FIG_INT]"`
+		Duration      time.Duration         `fig:"env[FIG_DURATION]"`
+		URL           *url.URL              `fig:"env[FIG_URL]"`
+		Text          textUnmarshalerValue  `fig:"env[FIG_TEXT]"`
+		TextRef       *textUnmarshalerValue `fig:"env[FIG_TEXT]"`
+		NotSetDefault int                   `fig:"env[FIG_NOT_SET]"`
+	}{NotSetDefault: 7}
+
+	FatalIfError(func() error {
+		return New(false).Initialize(holder)
+	})
+
+	if holder.Int != -42 || *holder.IntRef != -42 {
+		t.Errorf("Unexpected int value: %d", holder.Int)
+	}
+	if holder.Uint8 != 255 {
+		t.Errorf("Unexpected uint8 value: %d", holder.Uint8)
+	}
+	if !holder.Bool {
+		t.Error("Unexpected bool value")
+	}
+	if holder.Float != 0.25 {
+		t.Errorf("Unexpected float value: %f", holder.Float)
+	}
+	if holder.Duration != 90*time.Second {
+		t.Errorf("Unexpected duration value: %v", holder.Duration)
+	}
+	if holder.URL == nil || holder.URL.Host != "localhost:5432" {
+		t.Errorf("Unexpected url value: %v", holder.URL)
+	}
+	if holder.Text.value != "unmarshaled:text" || holder.TextRef.value != "unmarshaled:text" {
+		t.Errorf("Unexpected text unmarshaler value: %v", holder.Text)
+	}
+	if holder.NotSetDefault != 7 {
+		t.Error("Field must be untouched if environment variable is not set")
+	}
+}
+
+func TestInitialize_EnvVarTypedParseFailure(t *testing.T) {
+	os.Setenv("FIG_NOT_A_NUMBER", "abc")
+	defer os.Unsetenv("FIG_NOT_A_NUMBER")
+
+	injector := New(false)
+	for _, holder := range []interface{}{
+		&struct {
+			Port int `fig:"env[FIG_NOT_A_NUMBER]"`
+		}{},
+		&struct {
+			Enabled bool `fig:"env[FIG_NOT_A_NUMBER]"`
+		}{},
+		&struct {
+			Timeout time.Duration `fig:"env[FIG_NOT_A_NUMBER]"`
+		}{},
+		&struct {
+			Small int8 `fig:"env[FIG_OVERFLOW]"`
+		}{},
+		&struct {
+			Func func() `fig:"env[FIG_NOT_A_NUMBER]"`
+		}{},
+	} {
+		os.Setenv("FIG_OVERFLOW", "1000")
+		err := injector.Initialize(holder)
+		os.Unsetenv("FIG_OVERFLOW")
+		ExpectError(err, t, holder, ErrorIncorrectTagConfiguration)
+	}
+
+	err := injector.Initialize(&struct {
+		Port int `fig:"env[FIG_NOT_A_NUMBER]"`
+	}{})
+	if !strings.Contains(err.Error(), "FIG_NOT_A_NUMBER") || !strings.Contains(err.Error(), "Port") {
+		t.Errorf("Error must name environment variable and field: %v", err)
+	}
+}
+
+func TestInitialize_DefaultValues(t *testing.T) {
+	os.Setenv("FIG_PORT", "8081")
+	defer os.Unsetenv("FIG_PORT")
+
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.RegisterValue("host", "example.com")
+	})
+
+	holder := &struct {
+		Port        int           `fig:"env[FIG_PORT] default[8080]"`
+		Timeout     time.Duration `fig:"env[FIG_TIMEOUT] default[5s]"`
+		Host        string        `fig:"reg[host] default[localhost]"`
+		Retries     int           `fig:"reg[retries] default[3]"`
+		Name        string        `fig:"env[FIG_NAME] default[fig] required[true]"`
+		NotRequired string        `fig:"env[FIG_NAME] required[false]"`
+	}{}
+
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	if holder.Port != 8081 {
+		t.Errorf("Environment variable must take precedence over default value: %d", holder.Port)
+	}
+	if holder.Timeout != 5*time.Second {
+		t.Errorf("Default value was not set: %v", holder.Timeout)
+	}
+	if holder.Host != "example.com" {
+		t.Errorf("Registered value must take precedence over default value: %s", holder.Host)
+	}
+	if holder.Retries != 3 {
+		t.Errorf("Default value was not set: %d", holder.Retries)
+	}
+	if holder.Name != "fig" {
+		t.Errorf("Default value was not set: %s", holder.Name)
+	}
+	if holder.NotRequired != "" {
+		t.Errorf("Field must be untouched: %s", holder.NotRequired)
+	}
+}
+
+func TestInitialize_DefaultValueIncorrect(t *testing.T) {
+	injector := New(false)
+	for _, holder := range []interface{}{
+		&struct {
+			Port int `fig:"env[FIG_PORT] default[http]"`
+		}{},
+		&struct {
+			Port int `fig:"reg[port] default[http]"`
+		}{},
+		&struct {
+			Port int `fig:"env[FIG_PORT] required[yes]"`
+		}{},
+	} {
+		err := injector.Initialize(holder)
+		ExpectError(err, t, holder, ErrorIncorrectTagConfiguration)
+	}
+}
+
+type requiredValuesComponent struct {
+	Secret string `fig:"env[FIG_SECRET] required[true]"`
+}
+
+type requiredValuesHolder struct {
+	Port      int    `fig:"env[FIG_PORT] required[true]"`
+	Host      string `fig:"reg[host] required[true]"`
+	Component *requiredValuesComponent
+	Nested    struct {
+		URL string `fig:"env[FIG_URL] required[true]"`
+	}
+}
+
+func TestInitialize_RequiredValuesMissing(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(new(requiredValuesComponent))
+	})
+
+	err := injector.Initialize(new(requiredValuesHolder))
+	ExpectError(err, t, nil, ErrorRequiredValueMissing)
+	for _, expected := range []string{
+		"env[FIG_PORT] for field requiredValuesHolder.Port",
+		"reg[host] for field requiredValuesHolder.Host",
+		"env[FIG_SECRET] for field requiredValuesComponent.Secret",
+		"env[FIG_URL] for field URL",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Missing value %q is not reported in: %v", expected, err)
+		}
+	}
+}
+
+type lifecycleLog struct {
+	events []string
+}
+
+type lifecycleRepo struct {
+	Log *lifecycleLog `fig:"skip[true]"`
+	Err error         `fig:"skip[true]"`
+}
+
+func (lr *lifecycleRepo) Init() error {
+	lr.Log.events = append(lr.Log.events, "init repo")
+	return nil
+}
+
+func (lr *lifecycleRepo) Close() error {
+	lr.Log.events = append(lr.Log.events, "close repo")
+	return lr.Err
+}
+
+type lifecycleService struct {
+	Log  *lifecycleLog `fig:"skip[true]"`
+	Repo *lifecycleRepo
+	Err  error `fig:"skip[true]"`
+}
+
+func (ls *lifecycleService) Init() error {
+	if ls.Repo == nil {
+		return errors.New("repo is not injected")
+	}
+	ls.Log.events = append(ls.Log.events, "init service")
+	return nil
+}
+
+func (ls *lifecycleService) Close() error {
+	ls.Log.events = append(ls.Log.events, "close service")
+	return ls.Err
+}
+
+type lifecycleController struct {
+	Log     *lifecycleLog `fig:"skip[true]"`
+	Service *lifecycleService
+}
+
+func (lc *lifecycleController) Close() error {
+	lc.Log.events = append(lc.Log.events, "close controller")
+	return nil
+}
+
+func TestInitialize_Lifecycle(t *testing.T) {
+	log := new(lifecycleLog)
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(
+			&lifecycleController{Log: log},
+			&lifecycleService{Log: log},
+			&lifecycleRepo{Log: log},
+		)
+	})
+
+	holder := &struct {
+		Controller *lifecycleController
+	}{}
+
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	FatalIfError(injector.Close)
+
+	expected := "init repo, init service, close controller, close service, close repo"
+	if actual := strings.Join(log.events, ", "); actual != expected {
+		t.Errorf("Unexpected lifecycle events order: %s", actual)
+	}
+}
+
+func TestInitialize_LifecycleInitFailed(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() *lifecycleService {
+			return &lifecycleService{Log: new(lifecycleLog)}
+		})
+	})
+	err := injector.Initialize(&struct {
+		Service *lifecycleService
+	}{})
+	ExpectError(err, t, nil, ErrorInitializationFailed)
+}
+
+type retriedComponent struct {
+	Repo  *lifecycleRepo
+	Fails bool `fig:"skip[true]"`
+	Inits int  `fig:"skip[true]"`
+}
+
+func (rc *retriedComponent) Init() error {
+	rc.Inits++
+	if rc.Fails {
+		return errors.New("not ready yet")
+	}
+	return nil
+}
+
+func TestInitialize_RetryAfterInitFailed(t *testing.T) {
+	component := &retriedComponent{Fails: true}
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(component, &lifecycleRepo{Log: new(lifecycleLog)})
+	})
+
+	holder := &struct {
+		Component *retriedComponent
+	}{}
+	for attempt := 0; attempt < 2; attempt++ {
+		err := injector.Initialize(holder)
+		ExpectError(err, t, holder, ErrorInitializationFailed)
+		if holder.Component != nil {
+			t.Fatalf("Not initialized component must not be injected: %#v", holder.Component)
+		}
+	}
+
+	component.Fails = false
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Component != component || component.Inits != 3 || component.Repo == nil {
+		t.Errorf("Component must be assembled and initialized again: %#v", holder.Component)
+	}
+}
+
+func TestInitialize_RetryAfterProvidedInitFailed(t *testing.T) {
+	calls := 0
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() *retriedComponent {
+			calls++
+			return &retriedComponent{Fails: calls == 1}
+		})
+	})
+
+	holder := &struct {
+		Component *retriedComponent
+	}{}
+	err := injector.Initialize(holder)
+	ExpectError(err, t, holder, ErrorInitializationFailed)
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if calls != 2 || holder.Component == nil || holder.Component.Fails {
+		t.Errorf("Provider must be called again after failed initialization: %d, %#v", calls, holder.Component)
+	}
+}
+
+func TestInitialize_RetryAfterRequiredValueMissing(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(new(requiredValuesComponent))
+	})
+
+	holder := &struct {
+		Component *requiredValuesComponent
+	}{}
+	for attempt := 0; attempt < 2; attempt++ {
+		err := injector.Initialize(holder)
+		ExpectError(err, t, holder, ErrorRequiredValueMissing)
+	}
+
+	os.Setenv("FIG_SECRET", "secret")
+	defer os.Unsetenv("FIG_SECRET")
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Component == nil || holder.Component.Secret != "secret" {
+		t.Errorf("Component must be assembled again: %#v", holder.Component)
+	}
+}
+
+type selfInitializedService struct {
+	injector *Fig
+	Self     *selfInitializedService `fig:"skip[true]"`
+}
+
+func (sis *selfInitializedService) Init(ctx context.Context) error {
+	holder := &struct {
+		Service *selfInitializedService
+	}{}
+	if err := sis.injector.InitializeContext(ctx, holder); err != nil {
+		return err
+	}
+	sis.Self = holder.Service
+	return nil
+}
+
+func TestInitialize_ProvidedInitUsesItself(t *testing.T) {
+	calls := 0
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() *selfInitializedService {
+			calls++
+			return &selfInitializedService{injector: injector}
+		})
+	})
+
+	holder := &struct {
+		Service *selfInitializedService
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if calls != 1 || holder.Service == nil || holder.Service.Self != holder.Service {
+		t.Errorf("Init must get the object that is initialized: %d, %#v", calls, holder.Service)
+	}
+}
+
+func TestClose_Errors(t *testing.T) {
+	log := new(lifecycleLog)
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(
+			&lifecycleService{Log: log, Err: errors.New("service failure")},
+			&lifecycleRepo{Log: log, Err: errors.New("repo failure")},
+		)
+	})
+	FatalIfError(func() error {
+		return injector.Initialize(&struct{}{})
+	})
+
+	err := injector.Close()
+	ExpectError(err, t, nil, ErrorCloseFailed)
+	if !strings.Contains(err.Error(), "service failure") || !strings.Contains(err.Error(), "repo failure") {
+		t.Errorf("All close errors must be reported: %v", err)
+	}
+	if len(log.events) != 4 {
+		t.Errorf("All objects must be closed even if some of them failed: %v", log.events)
+	}
+	FatalIfError(injector.Close)
+}
+
+type Handler interface {
+	Handle() string
+}
+
+type handlerFirst struct{}
+
+func (*handlerFirst) Handle() string { return "first" }
+
+type handlerSecond struct{}
+
+func (*handlerSecond) Handle() string { return "second" }
+
+type handlerThird struct {
+	Name string `fig:"skip[true]"`
+}
+
+func (ht *handlerThird) Handle() string { return ht.Name }
+
+func TestInitialize_AllImplementations(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(
+			new(handlerSecond),
+			new(repos.MemOrderRepo),
+			new(handlerFirst),
+		)
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func() *handlerThird {
+			return &handlerThird{Name: "third"}
+		})
+	})
+
+	holder := &struct {
+		Handlers   []Handler             `fig:"all"`
+		UserRepos  []repos.UserRepo      `fig:"all"`
+		OrderRepos []*repos.MemOrderRepo `fig:"all size[10]"`
+	}{}
+
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	var handled []string
+	for _, handler := range holder.Handlers {
+		handled = append(handled, handler.Handle())
+	}
+	if actual := strings.Join(handled, ", "); actual != "second, first, third" {
+		t.Errorf("Unexpected handlers injected: %s", actual)
+	}
+	if holder.UserRepos == nil || len(holder.UserRepos) != 0 {
+		t.Errorf("Empty slice expected if there are no implementations: %#v", holder.UserRepos)
+	}
+	if len(holder.OrderRepos) != 1 {
+		t.Errorf("Unexpected order repos injected: %#v", holder.OrderRepos)
+	}
+}
+
+func TestInitialize_AllImplementationsUnsupportedType(t *testing.T) {
+	holder := &struct {
+		Names []string `fig:"all"`
+	}{}
+
+	err := New(false).Initialize(holder)
+	ExpectError(err, t, holder, ErrorIncorrectTagConfiguration)
+}
+
+type jsonHandler struct{}
+
+func (*jsonHandler) Handle() string  { return "json" }
+func (*jsonHandler) Qualify() string { return "application/json" }
+
+type xmlHandler struct{}
+
+func (*xmlHandler) Handle() string  { return "xml" }
+func (*xmlHandler) Qualify() string { return "application/xml" }
+
+type otherXMLHandler struct{}
+
+func (*otherXMLHandler) Handle() string  { return "other xml" }
+func (*otherXMLHandler) Qualify() string { return "application/xml" }
+
+type contentType string
+
+func TestInitialize_QualifiedImplementations(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(new(jsonHandler), new(xmlHandler), new(handlerFirst))
+	})
+
+	holder := &struct {
+		Handlers      map[string]Handler        `fig:"qualified"`
+		TypedHandlers map[contentType]Handler   `fig:"qualified"`
+		UserRepos     map[string]repos.UserRepo `fig:"qualified"`
+	}{}
+
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	expected := map[string]string{
+		"application/json":                        "json",
+		"application/xml":                         "xml",
+		"github.com/pavelmemory/fig/handlerFirst": "first",
+	}
+	if len(holder.Handlers) != len(expected) {
+		t.Errorf("Unexpected handlers injected: %#v", holder.Handlers)
+	}
+	for key, handled := range expected {
+		if handler, found := holder.Handlers[key]; !found || handler.Handle() != handled {
+			t.Errorf("Unexpected handler for key %s: %#v", key, handler)
+		}
+	}
+	if holder.TypedHandlers["application/json"] == nil {
+		t.Error("Keys of named string type must be supported")
+	}
+	if holder.UserRepos == nil || len(holder.UserRepos) != 0 {
+		t.Errorf("Empty map expected if there are no implementations: %#v", holder.UserRepos)
+	}
+}
+
+func TestInitialize_QualifiedImplementationsErrors(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(new(xmlHandler), new(otherXMLHandler))
+	})
+
+	holder := &struct {
+		Handlers map[string]Handler `fig:"qualified"`
+	}{}
+	err := injector.Initialize(holder)
+	ExpectError(err, t, holder, ErrorDuplicateQualifier)
+
+	for _, holder := range []interface{}{
+		&struct {
+			Handlers map[int]Handler `fig:"qualified"`
+		}{},
+		&struct {
+			Names map[string]string `fig:"qualified"`
+		}{},
+	} {
+		err := New(false).Initialize(holder)
+		ExpectError(err, t, holder, ErrorIncorrectTagConfiguration)
+	}
+}
+
+type orderedComponent struct {
+	Name   string    `fig:"skip[true]"`
+	Events *[]string `fig:"skip[true]"`
+}
+
+func (oc *orderedComponent) Init() error {
+	*oc.Events = append(*oc.Events, oc.Name)
+	return nil
+}
+
+type orderedComponentA struct {
+	orderedComponent `fig:"skip[true]"`
+}
+type orderedComponentB struct {
+	orderedComponent `fig:"skip[true]"`
+}
+type orderedComponentC struct {
+	orderedComponent `fig:"skip[true]"`
+}
+type orderedComponentD struct {
+	orderedComponent `fig:"skip[true]"`
+}
+
+func TestInitialize_RegistrationOrder(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		var events []string
+		injector := New(false)
+		FatalIfError(func() error {
+			return injector.Register(
+				&orderedComponentC{orderedComponent{Name: "C", Events: &events}},
+				&orderedComponentA{orderedComponent{Name: "A", Events: &events}},
+				&orderedComponentD{orderedComponent{Name: "D", Events: &events}},
+				&orderedComponentB{orderedComponent{Name: "B", Events: &events}},
+			)
+		})
+		FatalIfError(func() error {
+			return injector.Register(&orderedComponentA{orderedComponent{Name: "A2", Events: &events}})
+		})
+
+		FatalIfError(func() error {
+			return injector.Initialize(&struct{}{})
+		})
+		if actual := strings.Join(events, ", "); actual != "C, A2, D, B" {
+			t.Fatalf("Registered objects must be assembled in order of registration: %s", actual)
+		}
+
+		err := injector.Initialize(&struct {
+			Initializer
+		}{})
+		ExpectError(err, t, nil, ErrorCannotDecideImplementation)
+		expected := "\t*fig.orderedComponentC\n\t*fig.orderedComponentA\n\t*fig.orderedComponentD\n\t*fig.orderedComponentB\n"
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Candidates must be listed in order of registration: %v", err)
+		}
+	}
+}
+
+type concurrentComponent struct {
+	Store       *providedStore
+	initialized bool `fig:"skip[true]"`
+}
+
+func (cc *concurrentComponent) Init() error {
+	time.Sleep(10 * time.Millisecond)
+	cc.initialized = true
+	return nil
+}
+
+type concurrentHolder struct {
+	Component *concurrentComponent
+	Store     *providedStore
+	Orders    repos.OrderRepo
+	Value     int `fig:"reg[key0] default[-1]"`
+}
+
+func initializeConcurrently(injector *Fig, goroutines int, routine func(routine int) error) []error {
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for routineIndex := 0; routineIndex < goroutines; routineIndex++ {
+		wg.Add(1)
+		go func(routineIndex int) {
+			defer wg.Done()
+			if err := routine(routineIndex); err != nil {
+				errs <- err
+				return
+			}
+			holder := new(concurrentHolder)
+			if err := injector.Initialize(holder); err != nil {
+				errs <- err
+				return
+			}
+			if !holder.Component.initialized {
+				errs <- errors.New("object must be injected only after it is initialized")
+			}
+			if holder.Component.Store != holder.Store || holder.Store.Config.DSN != "mem://" {
+				errs <- fmt.Errorf("incorrect injection: %#v", holder)
+			}
+		}(routineIndex)
+	}
+	wg.Wait()
+	close(errs)
+	var result []error
+	for err := range errs {
+		result = append(result, err)
+	}
+	return result
+}
+
+func newConcurrentInjector() *Fig {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "mem://"}, new(repos.MemOrderRepo), new(concurrentComponent))
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func(cfg *providedConfig) *providedStore {
+			return &providedStore{Config: cfg}
+		})
+	})
+	return injector
+}
+
+func TestInitialize_ConcurrentWithRegistration(t *testing.T) {
+	injector := newConcurrentInjector()
+	for _, err := range initializeConcurrently(injector, 32, func(routine int) error {
+		if err := injector.RegisterValue(fmt.Sprintf("key%d", routine), routine); err != nil {
+			return err
+		}
+		if routine%8 == 0 {
+			return injector.Register(new(repos.MemUserRepo))
+		}
+		return nil
+	}) {
+		t.Error(err)
+	}
+}
+
+func TestInitialize_ConcurrentWaitsForAssembling(t *testing.T) {
+	injector := newConcurrentInjector()
+	for _, err := range initializeConcurrently(injector, 32, func(int) error {
+		return nil
+	}) {
+		t.Error(err)
+	}
+}
+
+type delegatingComponent struct {
+	injector *Fig           `fig:"skip[true]"`
+	Store    *providedStore `fig:"skip[true]"`
+}
+
+func (dc *delegatingComponent) Init(ctx context.Context) error {
+	holder := &struct {
+		Component *delegatingComponent
+		Store     *providedStore
+	}{}
+	done := make(chan error)
+	go func() {
+		done <- dc.injector.InitializeContext(ctx, holder)
+	}()
+	if err := <-done; err != nil {
+		return err
+	}
+	if holder.Component != dc {
+		return errors.New("component that is initialized must be injected")
+	}
+	dc.Store = holder.Store
+	return nil
+}
+
+func TestInitializeContext_FromInitInAnotherGoroutine(t *testing.T) {
+	injector := newConcurrentInjector()
+	component := &delegatingComponent{injector: injector}
+	FatalIfError(func() error {
+		return injector.Register(component)
+	})
+
+	holder := new(concurrentHolder)
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if component.Store == nil || component.Store != holder.Store {
+		t.Errorf("Init must get objects with context of initialization: %#v", component)
+	}
+}
+
+func TestRegister_ReplacedObjectIsAssembled(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "first"}, new(providedStore))
+	})
+	FatalIfError(func() error {
+		return injector.Initialize(&struct{}{})
+	})
+
+	FatalIfError(func() error {
+		return injector.Register(new(providedStore))
+	})
+	holder := &struct {
+		Store *providedStore
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	if holder.Store.Config == nil || holder.Store.Config.DSN != "first" {
+		t.Errorf("Replaced object must be assembled: %#v", holder.Store)
+	}
+}
+
+type replacingComponent struct {
+	injector    *Fig                `fig:"skip[true]"`
+	replacement *replacingComponent `fig:"skip[true]"`
+	Config      *providedConfig
+}
+
+func (rc *replacingComponent) Init() error {
+	if rc.replacement != nil {
+		return rc.injector.Register(rc.replacement)
+	}
+	return nil
+}
+
+func TestRegister_DuringAssemblyOfReplacedObject(t *testing.T) {
+	injector := New(false)
+	replacement := new(replacingComponent)
+	original := &replacingComponent{injector: injector, replacement: replacement}
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "mem://"}, original)
+	})
+
+	holder := &struct {
+		Component *replacingComponent
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if original.Config == nil || holder.Component != replacement || replacement.Config == nil {
+		t.Errorf("Object registered during assembly of replaced one must be assembled: %#v", holder.Component)
+	}
+}
+
+func TestProvide_DuringConstructionOfReplacedObject(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() *replacingComponent {
+			FatalIfError(func() error {
+				return injector.Provide(func() *replacingComponent {
+					return &replacingComponent{Config: &providedConfig{DSN: "second"}}
+				})
+			})
+			return &replacingComponent{Config: &providedConfig{DSN: "first"}}
+		})
+	})
+
+	holder := &struct {
+		Component *replacingComponent
+	}{}
+	for _, expected := range []string{"first", "second", "second"} {
+		FatalIfError(func() error {
+			return injector.Initialize(holder)
+		})
+		if holder.Component.Config.DSN != expected {
+			t.Errorf("Result of replaced provider must not be kept: %s instead of %s", holder.Component.Config.DSN, expected)
+		}
+	}
+}
+
+type cyclicNode struct {
+	Name string
+	Next *cyclicNode
+}
+
+type cyclicFirst struct {
+	Second *cyclicSecond
+}
+
+type cyclicSecond struct {
+	Third *cyclicThird
+}
+
+type cyclicThird struct {
+	First *cyclicFirst
+}
+
+func TestInitialize_DependencyCycleOfUnregisteredStructs(t *testing.T) {
+	injector := New(false)
+
+	holder := &struct {
+		Node *cyclicNode
+	}{}
+	err := injector.Initialize(holder)
+	ExpectError(err, t, holder, ErrorDependencyCycle)
+	if !strings.Contains(err.Error(), "Dependency cycle: fig.cyclicNode -> fig.cyclicNode.") {
+		t.Errorf("Unexpected cycle description: %v", err)
+	}
+
+	err = injector.Initialize(&struct {
+		First cyclicFirst
+	}{})
+	ExpectError(err, t, holder, ErrorDependencyCycle)
+	expected := "Dependency cycle: fig.cyclicFirst -> fig.cyclicSecond -> fig.cyclicThird -> fig.cyclicFirst."
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Unexpected cycle description: %v", err)
+	}
+}
+
+func TestInitialize_DependencyCycleOfProviders(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func(*cyclicSecond) *cyclicFirst {
+			return new(cyclicFirst)
+		})
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func(*cyclicFirst) (*cyclicSecond, error) {
+			return new(cyclicSecond), nil
+		})
+	})
+
+	holder := &struct {
+		First *cyclicFirst
+	}{}
+	err := injector.Initialize(holder)
+	ExpectError(err, t, holder, ErrorDependencyCycle)
+	expected := "Dependency cycle: func(*fig.cyclicSecond) *fig.cyclicFirst -> " +
+		"func(*fig.cyclicFirst) (*fig.cyclicSecond, error) -> func(*fig.cyclicSecond) *fig.cyclicFirst."
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Unexpected cycle description: %v", err)
+	}
+}
+
+// sameNamed has the same name as type declared in TestInitialize_TypesWithSameNameAreNotCycle.
+type sameNamed struct {
+	Value string `fig:"reg[value]"`
+}
+
+type sameNamedInner = sameNamed
+
+func TestInitialize_TypesWithSameNameAreNotCycle(t *testing.T) {
+	type sameNamed struct {
+		Inner sameNamedInner
+	}
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.RegisterValue("value", "inner")
+	})
+
+	holder := &struct {
+		Outer sameNamed
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Outer.Inner.Value != "inner" {
+		t.Errorf("Inner struct must be assembled: %#v", holder)
+	}
+}
+
+func TestInitialize_ReferenceBetweenProvidedAndRegisteredObjectIsNotCycle(t *testing.T) {
+	injector := New(false)
+	first := new(cyclicFirst)
+	FatalIfError(func() error {
+		return injector.Register(first)
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func(first *cyclicFirst) *cyclicSecond {
+			return &cyclicSecond{Third: &cyclicThird{First: first}}
+		})
+	})
+
+	FatalIfError(func() error {
+		return injector.Initialize(&struct{}{})
+	})
+	if first.Second == nil || first.Second.Third.First != first {
+		t.Error("Incorrect injection")
+	}
+}
+
+func TestChild_FallsBackToParent(t *testing.T) {
+	parent := New(false)
+	FatalIfError(func() error {
+		return parent.Register(&providedConfig{DSN: "parent"}, new(handlerFirst))
+	})
+	FatalIfError(func() error {
+		return parent.RegisterValue("name", "parent")
+	})
+	FatalIfError(func() error {
+		return parent.RegisterValue("size", 10)
+	})
+
+	child := parent.Child()
+	FatalIfError(func() error {
+		return child.Register(&providedConfig{DSN: "child"})
+	})
+	FatalIfError(func() error {
+		return child.RegisterValue("name", "child")
+	})
+
+	childHolder := &struct {
+		Config  *providedConfig
+		Handler Handler
+		Name    string `fig:"reg[name]"`
+		Size    int    `fig:"reg[size]"`
+	}{}
+	FatalIfError(func() error {
+		return child.Initialize(childHolder)
+	})
+	if childHolder.Config.DSN != "child" {
+		t.Errorf("Object registered in child expected: %#v", childHolder.Config)
+	}
+	if _, ok := childHolder.Handler.(*handlerFirst); !ok {
+		t.Errorf("Object registered in parent expected: %#v", childHolder.Handler)
+	}
+	if childHolder.Name != "child" || childHolder.Size != 10 {
+		t.Errorf("Unexpected values injected: %#v", childHolder)
+	}
+
+	parentHolder := &struct {
+		Config *providedConfig
+		Name   string `fig:"reg[name]"`
+	}{}
+	FatalIfError(func() error {
+		return parent.Initialize(parentHolder)
+	})
+	if parentHolder.Config.DSN != "parent" || parentHolder.Name != "parent" {
+		t.Errorf("Child registrations must not be visible to parent: %#v", parentHolder)
+	}
+}
+
+func TestChild_AllImplementations(t *testing.T) {
+	parent := New(false)
+	FatalIfError(func() error {
+		return parent.Register(new(handlerFirst), new(handlerSecond))
+	})
+	child := parent.Child()
+	FatalIfError(func() error {
+		return child.Register(&handlerThird{Name: "third"}, new(handlerFirst))
+	})
+
+	holder := &struct {
+		Handlers []Handler `fig:"all"`
+	}{}
+	FatalIfError(func() error {
+		return child.Initialize(holder)
+	})
+
+	var handled []string
+	for _, handler := range holder.Handlers {
+		handled = append(handled, handler.Handle())
+	}
+	if actual := strings.Join(handled, ", "); actual != "third, first, second" {
+		t.Errorf("Unexpected handlers injected: %s", actual)
+	}
+}
+
+type prototypeBuffer struct {
+	Config *providedConfig
+	Prefix string `fig:"skip[true]"`
+	Data   []string
+}
+
+type prototypeConsumer struct {
+	Buffer *prototypeBuffer
+}
+
+func TestRegisterPrototype(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "db"}, new(prototypeConsumer))
+	})
+	FatalIfError(func() error {
+		return injector.RegisterPrototype(&prototypeBuffer{Prefix: "> "})
+	})
+
+	holder := &struct {
+		First    *prototypeBuffer
+		Second   *prototypeBuffer
+		Consumer *prototypeConsumer
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	if holder.First == holder.Second || holder.First == holder.Consumer.Buffer {
+		t.Fatal("New instance of prototype expected for every injection")
+	}
+	for _, buffer := range []*prototypeBuffer{holder.First, holder.Second, holder.Consumer.Buffer} {
+		if buffer.Prefix != "> " || buffer.Config == nil || buffer.Config.DSN != "db" {
+			t.Errorf("Prototype instance is not copied from template or not assembled: %#v", buffer)
+		}
+	}
+	holder.First.Data = append(holder.First.Data, "data")
+	if len(holder.Second.Data) != 0 {
+		t.Error("Prototype instances must not share state")
+	}
+}
+
+func TestProvidePrototype(t *testing.T) {
+	injector := New(false)
+	log := new(lifecycleLog)
+	var calls int
+	FatalIfError(func() error {
+		return injector.ProvidePrototype(func() *lifecycleRepo {
+			calls++
+			return &lifecycleRepo{Log: log}
+		})
+	})
+
+	holder := &struct {
+		First  *lifecycleRepo
+		Second *lifecycleRepo
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	if calls != 2 || holder.First == holder.Second {
+		t.Errorf("Provider of prototype must be called for every injection, calls: %d", calls)
+	}
+	FatalIfError(func() error {
+		return injector.Close()
+	})
+	if actual := strings.Join(log.events, ", "); actual != "init repo, init repo" {
+		t.Errorf("Every prototype instance must be initialized, but not closed: %s", actual)
+	}
+}
+
+func TestRegisterPrototype_InstancesAreNotKept(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "db"})
+	})
+	FatalIfError(func() error {
+		return injector.RegisterPrototype(new(prototypeBuffer))
+	})
+	FatalIfError(func() error {
+		return injector.ProvidePrototype(func() *lifecycleRepo {
+			return &lifecycleRepo{Log: new(lifecycleLog)}
+		})
+	})
+
+	for i := 0; i < 10; i++ {
+		holder := &struct {
+			Buffer *prototypeBuffer
+			Repo   *lifecycleRepo
+		}{}
+		FatalIfError(func() error {
+			return injector.Initialize(holder)
+		})
+	}
+	if len(injector.components) != 1 {
+		t.Errorf("Only singleton must be kept by injector: %d", len(injector.components))
+	}
+}
+
+func TestRegisterPrototype_SelfReference(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.RegisterPrototype(new(cyclicNode))
+	})
+
+	err := injector.Initialize(&struct{ Node *cyclicNode }{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorDependencyCycle {
+		t.Errorf("Dependency cycle error expected: %v", err)
+	}
+}
+
+type boundHandler struct{}
+
+func (*boundHandler) Handle() string { return "bound" }
+func (*boundHandler) String() string { return "bound handler" }
+
+func TestRegisterAs_BindsOnlyNamedInterfaces(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.RegisterAs(new(boundHandler), (*Handler)(nil))
+	})
+	FatalIfError(func() error {
+		return injector.Register(new(handlerFirst))
+	})
+
+	holder := &struct {
+		Handlers []Handler `fig:"all"`
+		Bound    *boundHandler
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if len(holder.Handlers) != 2 || holder.Bound == nil {
+		t.Errorf("Unexpected injection: %#v", holder)
+	}
+
+	err := injector.Initialize(&struct{ Stringer fmt.Stringer }{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotDecideImplementation {
+		t.Errorf("Object must not be injected into interface it is not bound to: %v", err)
+	}
+}
+
+func TestRegisterAs_IncorrectInterfaces(t *testing.T) {
+	injector := New(false)
+	for _, ifaces := range [][]interface{}{
+		nil,
+		{nil},
+		{new(handlerFirst)},
+		{(*Handler)(nil), (*boundHandler)(nil)},
+		{(*repos.UserRepo)(nil)},
+	} {
+		err := injector.RegisterAs(new(boundHandler), ifaces...)
+		if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotBeRegistered {
+			t.Errorf("Registration error expected for %v: %v", ifaces, err)
+		}
+	}
+}
+
+func TestNew_WithoutImplicitInterfaces(t *testing.T) {
+	injector := New(false, WithoutImplicitInterfaces())
+	FatalIfError(func() error {
+		return injector.Register(new(handlerFirst), new(handlerSecond))
+	})
+	FatalIfError(func() error {
+		return injector.RegisterAs(new(boundHandler), (*Handler)(nil), (*fmt.Stringer)(nil))
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func() repos.UserRepo {
+			return new(providedStore)
+		})
+	})
+
+	holder := &struct {
+		Handler  Handler
+		Stringer fmt.Stringer
+		Repo     repos.UserRepo
+		First    *handlerFirst
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Handler.Handle() != "bound" || holder.Stringer.String() != "bound handler" {
+		t.Errorf("Only explicitly bound implementation expected: %#v", holder)
+	}
+	if holder.Repo == nil || holder.First == nil {
+		t.Errorf("Objects must be injected into fields of their own types: %#v", holder)
+	}
+}
+
+type primaryHandler struct {
+	IsPrimary bool   `fig:"skip[true]"`
+	Name      string `fig:"skip[true]"`
+}
+
+func (ph *primaryHandler) Handle() string { return ph.Name }
+func (ph *primaryHandler) Primary() bool  { return ph.IsPrimary }
+
+type otherPrimaryHandler struct {
+	primaryHandler `fig:"skip[true]"`
+}
+
+func TestInitialize_PrimaryImplementation(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(new(handlerFirst), &primaryHandler{IsPrimary: true, Name: "primary"}, new(handlerSecond))
+	})
+
+	holder := &struct {
+		Handler  Handler
+		Explicit Handler `fig:"impl[github.com/pavelmemory/fig/handlerSecond]"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Handler.Handle() != "primary" {
+		t.Errorf("Primary implementation expected: %s", holder.Handler.Handle())
+	}
+	if holder.Explicit.Handle() != "second" {
+		t.Errorf("Explicit configuration must override primary: %s", holder.Explicit.Handle())
+	}
+}
+
+func TestInitialize_MultiplePrimaryImplementations(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(
+			&primaryHandler{IsPrimary: true},
+			&otherPrimaryHandler{primaryHandler{IsPrimary: true}},
+			new(handlerFirst),
+		)
+	})
+
+	err := injector.Initialize(&struct{ Handler Handler }{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotDecideImplementation ||
+		!strings.Contains(figErr.Cause, "Multiple primary implementations") ||
+		strings.Contains(figErr.Cause, "handlerFirst") {
+		t.Errorf("Error about multiple primary implementations expected: %v", err)
+	}
+}
+
+type regPort uint16
+
+func TestInitialize_RegisteredValueConversion(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.RegisterValues(map[string]interface{}{
+			"int":      42,
+			"float":    2.0,
+			"fraction": 2.5,
+			"negative": -1,
+			"big":      int64(1) << 40,
+			"timeout":  "150ms",
+			"url":      "https://example.com/path",
+			"ints":     []interface{}{1, int8(2), "3"},
+		})
+	})
+
+	holder := &struct {
+		Int64    int64         `fig:"reg[int]"`
+		Port     regPort       `fig:"reg[int]"`
+		Float    float32       `fig:"reg[int]"`
+		Rounded  uint8         `fig:"reg[float]"`
+		Pointer  *int64        `fig:"reg[int]"`
+		Fraction float64       `fig:"reg[fraction]"`
+		Timeout  time.Duration `fig:"reg[timeout]"`
+		URL      *url.URL      `fig:"reg[url]"`
+		Ints     []int32       `fig:"reg[ints]"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Int64 != 42 || holder.Port != 42 || holder.Float != 42 || holder.Rounded != 2 ||
+		*holder.Pointer != 42 || holder.Fraction != 2.5 || holder.Timeout != 150*time.Millisecond ||
+		holder.URL.Host != "example.com" || len(holder.Ints) != 3 || holder.Ints[2] != 3 {
+		t.Errorf("Unexpected values: %#v", holder)
+	}
+
+	for _, incorrect := range []interface{}{
+		&struct {
+			F int `fig:"reg[fraction]"`
+		}{},
+		&struct {
+			F uint `fig:"reg[negative]"`
+		}{},
+		&struct {
+			F int32 `fig:"reg[big]"`
+		}{},
+		&struct {
+			F time.Duration `fig:"reg[url]"`
+		}{},
+		&struct {
+			F string `fig:"reg[int]"`
+		}{},
+		&struct {
+			F []string `fig:"reg[timeout]"`
+		}{},
+	} {
+		err := injector.Initialize(incorrect)
+		figErr, ok := err.(FigError)
+		if !ok || figErr.Error_ != ErrorIncorrectTagConfiguration || !strings.Contains(figErr.Cause, "Registered value") {
+			t.Errorf("Error about incorrect registered value expected for %T: %v", incorrect, err)
+		}
+	}
+
+	err := injector.Initialize(&struct {
+		Count int16 `fig:"reg[big]"`
+	}{})
+	if err == nil || !strings.Contains(err.Error(), "big of type int64 can't be assigned to field Count of type int16") {
+		t.Errorf("Error with key, stored type and field type expected: %v", err)
+	}
+}
+
+type unexportedFields struct {
+	Name     string
+	handlers map[string]Handler
+	repo     repos.UserRepo `fig:"skip[true]"`
+}
+
+type registeredByValue struct {
+	Handlers map[string]Handler
+}
+
+func TestInitialize_UnexportedFields(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(new(repos.MemUserRepo))
+	})
+
+	holder := &struct {
+		Fields *unexportedFields
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Fields.handlers != nil || holder.Fields.repo != nil {
+		t.Errorf("Unexported fields must be skipped: %#v", holder.Fields)
+	}
+
+	err := injector.Initialize(&struct {
+		repo repos.UserRepo `fig:"impl[github.com/pavelmemory/fig/examples/justpackage/repos/MemUserRepo]"`
+	}{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorIncorrectTagConfiguration ||
+		!strings.Contains(figErr.Cause, "Unexported field repo") {
+		t.Errorf("Error about unexported field expected: %v", err)
+	}
+}
+
+func TestInitialize_ReflectPanicReturnedAsError(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(registeredByValue{})
+	})
+
+	err := injector.Initialize(&struct{ Value registeredByValue }{})
+	figErr, ok := err.(FigError)
+	if !ok || figErr.Error_ != ErrorCannotBeInjected || !strings.Contains(figErr.Cause, "fig.registeredByValue") ||
+		!strings.Contains(figErr.Cause, "registeredByValue.Handlers can't be set") {
+		t.Errorf("Error with assembling chain expected: %v", err)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != "reflect: provider panic" {
+			t.Errorf("Panic not caused by reflect must be propagated: %v", recovered)
+		}
+	}()
+	injector = New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() *providedConfig {
+			panic("reflect: provider panic")
+		})
+	})
+	injector.Initialize(&struct{ Config *providedConfig }{})
+}
@@ -0,0 +1,63 @@
+package fig
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// Lazy is a field type which value is resolved only when Get is called for the first time.
+// Configuration of `fig` tag of the field is applied to the resolved value.
+// Resolution is done only once, following calls of Get return the same value and error.
+//
+//	type Service struct {
+//		Reports fig.Lazy[*ReportGenerator]
+//	}
+type Lazy[T any] struct {
+	once  sync.Once
+	fig   *Fig
+	tag   reflect.StructTag
+	ctx   context.Context
+	value T
+	err   error
+}
+
+// lazyBinder is implemented by all instantiations of Lazy.
+type lazyBinder interface {
+	bind(fig *Fig, tag reflect.StructTag, ctx context.Context)
+	valueType() reflect.Type
+}
+
+// bind keeps context of the assembly that injected Lazy, so Get called from Init method
+// of the object that is being constructed doesn't wait until its construction is finished.
+func (lazy *Lazy[T]) bind(fig *Fig, tag reflect.StructTag, ctx context.Context) {
+	lazy.fig, lazy.tag, lazy.ctx = fig, tag, ctx
+}
+
+func (lazy *Lazy[T]) valueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func lazyOf(field reflect.Value) (lazyBinder, bool) {
+	if field.Kind() != reflect.Struct || !field.CanAddr() || !field.CanInterface() {
+		return nil, false
+	}
+	lazy, ok := field.Addr().Interface().(lazyBinder)
+	return lazy, ok
+}
+
+// Get resolves value on the first call and returns it. Error is returned if the value
+// can't be resolved or if Lazy was not initialized by Fig.
+func (lazy *Lazy[T]) Get() (T, error) {
+	lazy.once.Do(func() {
+		if lazy.fig == nil {
+			lazy.err = FigError{
+				Cause:  "Lazy value was not initialized by fig: " + reflect.TypeOf(lazy).Elem().String(),
+				Error_: ErrorCannotDecideImplementation,
+			}
+			return
+		}
+		lazy.err = lazy.fig.resolveValue(lazy.ctx, reflect.ValueOf(&lazy.value).Elem(), lazy.tag)
+	})
+	return lazy.value, lazy.err
+}
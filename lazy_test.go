@@ -0,0 +1,183 @@
+package fig
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pavelmemory/fig/examples/justpackage/repos"
+)
+
+type lazyReport struct {
+	Config *providedConfig
+}
+
+type lazyService struct {
+	Report  Lazy[*lazyReport]
+	Repo    Lazy[repos.UserRepo]
+	Timeout Lazy[int]    `fig:"env[FIG_LAZY_TIMEOUT] default[30]"`
+	Name    Lazy[string] `fig:"reg[lazy.name]"`
+}
+
+func TestLazy(t *testing.T) {
+	injector := New(false)
+	var calls int
+	FatalIfError(func() error {
+		return injector.Provide(func(config *providedConfig) *lazyReport {
+			calls++
+			return &lazyReport{Config: config}
+		})
+	})
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "db"}, new(providedStore))
+	})
+	FatalIfError(func() error {
+		return injector.RegisterValue("lazy.name", "lazy")
+	})
+	os.Unsetenv("FIG_LAZY_TIMEOUT")
+
+	service := new(lazyService)
+	FatalIfError(func() error {
+		return injector.Initialize(service)
+	})
+	if calls != 0 {
+		t.Fatal("Lazy value must not be resolved before Get")
+	}
+
+	for i := 0; i < 2; i++ {
+		report, err := service.Report.Get()
+		FatalIfError(func() error {
+			return err
+		})
+		if report.Config == nil || report.Config.DSN != "db" {
+			t.Errorf("Unexpected lazy value: %#v", report)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Lazy value must be resolved once, calls: %d", calls)
+	}
+	if repo, err := service.Repo.Get(); err != nil || repo == nil {
+		t.Errorf("Implementation of interface expected: %#v, %v", repo, err)
+	}
+	if timeout, err := service.Timeout.Get(); err != nil || timeout != 30 {
+		t.Errorf("Default value expected: %d, %v", timeout, err)
+	}
+	if name, err := service.Name.Get(); err != nil || name != "lazy" {
+		t.Errorf("Registered value expected: %s, %v", name, err)
+	}
+}
+
+func TestLazy_ErrorReturnedByGet(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Provide(func() (*lazyReport, error) {
+			return nil, os.ErrNotExist
+		})
+	})
+
+	service := &struct {
+		Report Lazy[*lazyReport]
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(service)
+	})
+
+	_, err := service.Report.Get()
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorProviderFailed {
+		t.Errorf("Provider error expected: %v", err)
+	}
+	if _, again := service.Report.Get(); again != err {
+		t.Errorf("The same error expected: %v", again)
+	}
+
+	var notInitialized Lazy[*lazyReport]
+	if _, err := notInitialized.Get(); err == nil {
+		t.Error("Error expected for Lazy not initialized by fig")
+	}
+}
+
+type lazyInitialized struct {
+	Report Lazy[*lazyReport]
+	report *lazyReport `fig:"skip[true]"`
+}
+
+func (li *lazyInitialized) Init() (err error) {
+	li.report, err = li.Report.Get()
+	return err
+}
+
+func initializeWithTimeout(t *testing.T, injector *Fig, holder interface{}) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- injector.Initialize(holder)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Initialize is blocked: %#v", holder)
+		return nil
+	}
+}
+
+func TestLazy_GetInInit(t *testing.T) {
+	injector := New(false)
+	component := new(lazyInitialized)
+	FatalIfError(func() error {
+		return injector.Register(component, new(lazyReport), &providedConfig{DSN: "db"})
+	})
+
+	FatalIfError(func() error {
+		return initializeWithTimeout(t, injector, &struct{}{})
+	})
+	if component.report == nil || component.report.Config == nil {
+		t.Errorf("Lazy value must be resolved in Init: %#v", component.report)
+	}
+}
+
+func TestLazy_ConcurrentGet(t *testing.T) {
+	injector := New(false)
+	var calls int32
+	FatalIfError(func() error {
+		return injector.Provide(func(config *providedConfig) *lazyReport {
+			atomic.AddInt32(&calls, 1)
+			return &lazyReport{Config: config}
+		})
+	})
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "db"})
+	})
+
+	service := new(lazyService)
+	FatalIfError(func() error {
+		return injector.Initialize(service)
+	})
+
+	reports := make(chan *lazyReport, 16)
+	var wg sync.WaitGroup
+	for routine := 0; routine < cap(reports); routine++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report, err := service.Report.Get()
+			if err != nil {
+				t.Error(err)
+			}
+			reports <- report
+		}()
+	}
+	wg.Wait()
+	close(reports)
+
+	first := <-reports
+	for report := range reports {
+		if report != first {
+			t.Errorf("All goroutines must get the same value: %p != %p", report, first)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Lazy value must be resolved once, calls: %d", calls)
+	}
+}
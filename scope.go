@@ -0,0 +1,93 @@
+package fig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type scope int
+
+const (
+	// single object is shared by all injections
+	singletonScope scope = iota
+	// new object is created for every injection
+	prototypeScope
+	// single object is shared during request
+	requestScope
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+type requestScopeKey struct{}
+
+// scopedKey identifies request scoped object, the same type can be registered in parent and child injectors.
+type scopedKey struct {
+	owner   *Fig
+	regType reflect.Type
+}
+
+type requestInstances struct {
+	mu        sync.Mutex
+	instances map[scopedKey]interface{}
+	// created keeps order of creation, so instances are closed in reverse order
+	created []interface{}
+	ended   bool
+}
+
+// NewRequestScope returns context that keeps request scoped objects created by InitializeContext
+// and function that ends the scope: it calls Close method of all request scoped objects
+// that implement Closer in reverse order of their creation.
+func NewRequestScope(ctx context.Context) (context.Context, func() error) {
+	scoped := &requestInstances{instances: make(map[scopedKey]interface{})}
+	end := func() error {
+		scoped.mu.Lock()
+		created := scoped.created
+		scoped.created = nil
+		scoped.instances = make(map[scopedKey]interface{})
+		scoped.ended = true
+		scoped.mu.Unlock()
+		return closeAll(created)
+	}
+	return context.WithValue(ctx, requestScopeKey{}, scoped), end
+}
+
+// resolveRequestScoped returns object registered in the owner that is cached in request scope.
+// If there is no such object yet it is created and assembled by fig.
+func (fig *Fig) resolveRequestScoped(owner *Fig, regType reflect.Type, regObject interface{}, asm *assembly) (interface{}, error) {
+	scoped, _ := asm.ctx.Value(requestScopeKey{}).(*requestInstances)
+	if scoped == nil {
+		return nil, FigError{
+			Cause:  fmt.Sprintf("%s is request scoped, it can be injected only by InitializeContext with context created by NewRequestScope", regType),
+			Error_: ErrorRequestScopeMissing,
+		}
+	}
+
+	key := scopedKey{owner: owner, regType: regType}
+	scoped.mu.Lock()
+	instance, found := scoped.instances[key]
+	ended := scoped.ended
+	scoped.mu.Unlock()
+	if ended {
+		return nil, FigError{Cause: "request scope is already ended", Error_: ErrorRequestScopeMissing}
+	}
+	if found {
+		return instance, nil
+	}
+
+	// lock is not held during creation because the instance can depend on other request scoped objects
+	instance, err := fig.newInstance(owner, regType, regObject, asm)
+	if err != nil {
+		return nil, err
+	}
+	scoped.mu.Lock()
+	defer scoped.mu.Unlock()
+	// instance created concurrently is closed with others, but only the first one is shared
+	scoped.created = append(scoped.created, instance)
+	if existing, found := scoped.instances[key]; found {
+		return existing, nil
+	}
+	scoped.instances[key] = instance
+	return instance, nil
+}
@@ -0,0 +1,156 @@
+package fig
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+type requestLogger struct {
+	Ctx context.Context
+	Log *lifecycleLog `fig:"skip[true]"`
+}
+
+func (rl *requestLogger) RequestID() string {
+	requestID, _ := rl.Ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+func (rl *requestLogger) Close() error {
+	rl.Log.events = append(rl.Log.events, "close logger "+rl.RequestID())
+	return nil
+}
+
+type requestTx struct {
+	ID     string
+	Logger *requestLogger
+}
+
+type requestHandler struct {
+	Config *providedConfig
+	Logger *requestLogger
+	Tx     *requestTx
+	Ctx    context.Context
+}
+
+func newRequestInjector(log *lifecycleLog) *Fig {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "db"})
+	})
+	FatalIfError(func() error {
+		return injector.RegisterRequestScoped(&requestLogger{Log: log})
+	})
+	FatalIfError(func() error {
+		return injector.ProvideRequestScoped(func(ctx context.Context, logger *requestLogger) *requestTx {
+			requestID, _ := ctx.Value(requestIDKey{}).(string)
+			return &requestTx{ID: "tx " + requestID, Logger: logger}
+		})
+	})
+	return injector
+}
+
+func TestInitializeContext(t *testing.T) {
+	log := new(lifecycleLog)
+	injector := newRequestInjector(log)
+
+	handle := func(requestID string) (*requestHandler, *requestHandler) {
+		ctx, end := NewRequestScope(context.WithValue(context.Background(), requestIDKey{}, requestID))
+		defer func() {
+			FatalIfError(end)
+		}()
+		first, second := new(requestHandler), new(requestHandler)
+		FatalIfError(func() error {
+			return injector.InitializeContext(ctx, first)
+		})
+		FatalIfError(func() error {
+			return injector.InitializeContext(ctx, second)
+		})
+		if first.Ctx != ctx {
+			t.Error("Context passed to InitializeContext expected")
+		}
+		return first, second
+	}
+
+	first, second := handle("1")
+	if first.Logger != second.Logger || first.Tx != second.Tx || first.Tx.Logger != first.Logger {
+		t.Error("Request scoped objects must be shared in the same scope")
+	}
+	if first.Logger.RequestID() != "1" || first.Tx.ID != "tx 1" {
+		t.Errorf("Unexpected request scoped objects: %#v, %#v", first.Logger, first.Tx)
+	}
+
+	other, _ := handle("2")
+	if other.Logger == first.Logger || other.Tx == first.Tx {
+		t.Error("Request scoped objects must not be shared between scopes")
+	}
+	if other.Config != first.Config {
+		t.Error("Singletons must be shared between scopes")
+	}
+	if actual := strings.Join(log.events, ", "); actual != "close logger 1, close logger 2" {
+		t.Errorf("Request scoped objects must be closed at the end of the scope: %s", actual)
+	}
+}
+
+func TestInitializeContext_WithoutScope(t *testing.T) {
+	injector := newRequestInjector(new(lifecycleLog))
+
+	holder := &struct {
+		Ctx    context.Context
+		Config *providedConfig
+	}{}
+	FatalIfError(func() error {
+		return injector.InitializeContext(context.Background(), holder)
+	})
+	if holder.Ctx != context.Background() || holder.Config == nil {
+		t.Errorf("Context and singletons expected: %#v", holder)
+	}
+
+	err := injector.InitializeContext(context.Background(), new(requestHandler))
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorRequestScopeMissing {
+		t.Errorf("Error about missing request scope expected: %v", err)
+	}
+	err = injector.Initialize(&struct{ Logger *requestLogger }{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorRequestScopeMissing {
+		t.Errorf("Error about missing request scope expected: %v", err)
+	}
+}
+
+type contextualService struct {
+	RequestID string
+}
+
+func TestInitializeContext_ProvidersRegisteredInParent(t *testing.T) {
+	parent := New(false)
+	FatalIfError(func() error {
+		return parent.ProvidePrototype(func(ctx context.Context) *contextualService {
+			requestID, _ := ctx.Value(requestIDKey{}).(string)
+			return &contextualService{RequestID: requestID}
+		})
+	})
+	FatalIfError(func() error {
+		return parent.Provide(func(ctx context.Context) *requestTx {
+			requestID, _ := ctx.Value(requestIDKey{}).(string)
+			return &requestTx{ID: "tx " + requestID}
+		})
+	})
+	child := parent.Child()
+
+	holder := &struct {
+		Service *contextualService
+		Tx      *requestTx
+	}{}
+	FatalIfError(func() error {
+		return child.InitializeContext(context.WithValue(context.Background(), requestIDKey{}, "1"), holder)
+	})
+	if holder.Service.RequestID != "1" || holder.Tx.ID != "tx 1" {
+		t.Errorf("Providers registered in parent must get the context: %#v, %#v", holder.Service, holder.Tx)
+	}
+
+	err := child.InitializeContext(nil, holder)
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotBeHolder {
+		t.Errorf("Error about nil context expected: %v", err)
+	}
+}
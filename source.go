@@ -0,0 +1,122 @@
+package fig
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// ValueSource provides values for `env` and `reg` configurations. Values that are not of type
+// of the field are converted at the time of injection the same way as registered values.
+type ValueSource interface {
+	Lookup(key string) (interface{}, bool)
+}
+
+// MapSource is ValueSource backed by map, it can be used instead of environment in tests.
+type MapSource map[string]interface{}
+
+func (source MapSource) Lookup(key string) (interface{}, bool) {
+	value, found := source[key]
+	return value, found
+}
+
+type osEnvSource struct{}
+
+func (osEnvSource) Lookup(key string) (interface{}, bool) {
+	return os.LookupEnv(key)
+}
+
+// OSEnv returns ValueSource of environment variables of the process.
+func OSEnv() ValueSource {
+	return osEnvSource{}
+}
+
+// DotenvSource parses file in .env format into ValueSource that can be put into chain of WithEnvSources
+// or WithRegSources. Variables are interpolated from sources passed as interpolation in order,
+// or from environment of the process if there are none. Unlike LoadDotenv it doesn't depend
+// on injector, so it is the way to build chains of sources, while LoadDotenv adds variables
+// on top of env sources of the injector, which are also used for interpolation.
+func DotenvSource(reader io.Reader, interpolation ...ValueSource) (ValueSource, error) {
+	if len(interpolation) == 0 {
+		interpolation = []ValueSource{OSEnv()}
+	}
+	parsed, err := readDotenv(reader, lookupString(func(key string) (interface{}, bool) {
+		for _, source := range interpolation {
+			if value, found := source.Lookup(key); found {
+				return value, true
+			}
+		}
+		return nil, false
+	}))
+	if err != nil {
+		return nil, err
+	}
+	source := make(MapSource, len(parsed))
+	for key, value := range parsed {
+		source[key] = value
+	}
+	return source, nil
+}
+
+// WithEnvSources replaces environment of the process with sources for `env` configuration.
+// Sources are looked up in order, the first one that has the key wins.
+// Variables loaded by LoadDotenv are used according to EnvPrecedence.
+func WithEnvSources(sources ...ValueSource) Option {
+	return func(fig *Fig) {
+		fig.envSources = append([]ValueSource{}, sources...)
+	}
+}
+
+// WithRegSources adds sources for `reg` configuration that are looked up in order
+// if value is not registered with RegisterValue, RegisterValues or LoadValuesJSON.
+func WithRegSources(sources ...ValueSource) Option {
+	return func(fig *Fig) {
+		fig.regSources = append([]ValueSource{}, sources...)
+	}
+}
+
+// lookupSources looks up the key in sources of the nearest injector that has them.
+func (fig *Fig) lookupSources(key string, sourcesOf func(injector *Fig) []ValueSource) (interface{}, bool, bool) {
+	for injector := fig; injector != nil; injector = injector.parent {
+		if sources := sourcesOf(injector); sources != nil {
+			for _, source := range sources {
+				if value, found := source.Lookup(key); found {
+					return value, true, true
+				}
+			}
+			return nil, false, true
+		}
+	}
+	return nil, false, false
+}
+
+func (fig *Fig) lookupEnvSources(key string) (interface{}, bool) {
+	value, found, configured := fig.lookupSources(key, func(injector *Fig) []ValueSource {
+		return injector.envSources
+	})
+	if !configured {
+		return OSEnv().Lookup(key)
+	}
+	return value, found
+}
+
+func (fig *Fig) lookupRegSources(key string) (interface{}, bool) {
+	value, found, _ := fig.lookupSources(key, func(injector *Fig) []ValueSource {
+		return injector.regSources
+	})
+	return value, found
+}
+
+// lookupString converts values found by lookup into strings for interpolation of variables in dotenv files.
+func lookupString(lookup func(key string) (interface{}, bool)) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, found := lookup(key)
+		if !found {
+			return "", false
+		}
+		if text, isString := value.(string); isString {
+			return text, true
+		}
+		return fmt.Sprint(value), true
+	}
+}
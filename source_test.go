@@ -0,0 +1,100 @@
+package fig
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithEnvSources(t *testing.T) {
+	os.Setenv("FIG_SOURCE_PROCESS", "process")
+	defer os.Unsetenv("FIG_SOURCE_PROCESS")
+	dotenv, err := DotenvSource(strings.NewReader("FIG_SOURCE_NAME=file\nFIG_SOURCE_TIMEOUT=1s\n"))
+	FatalIfError(func() error {
+		return err
+	})
+	injector := New(false, WithEnvSources(
+		MapSource{"FIG_SOURCE_NAME": "map", "FIG_SOURCE_RETRIES": 3},
+		dotenv,
+	))
+
+	holder := &struct {
+		Name    string        `fig:"env[FIG_SOURCE_NAME]"`
+		Timeout time.Duration `fig:"env[FIG_SOURCE_TIMEOUT]"`
+		Retries int           `fig:"env[FIG_SOURCE_RETRIES]"`
+		Process string        `fig:"env[FIG_SOURCE_PROCESS] default[replaced]"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Child().Initialize(holder)
+	})
+	if holder.Name != "map" || holder.Timeout != time.Second || holder.Retries != 3 || holder.Process != "replaced" {
+		t.Errorf("Unexpected values: %#v", holder)
+	}
+
+	withProcess := New(false, WithEnvSources(MapSource{}, OSEnv()))
+	FatalIfError(func() error {
+		return withProcess.Initialize(holder)
+	})
+	if holder.Process != "process" {
+		t.Errorf("Variable of the process expected: %s", holder.Process)
+	}
+}
+
+func TestWithRegSources(t *testing.T) {
+	injector := New(false, WithRegSources(
+		MapSource{"name": "first", "port": "8080"},
+		MapSource{"name": "second", "host": "localhost"},
+	))
+	FatalIfError(func() error {
+		return injector.RegisterValue("host", "registered")
+	})
+
+	holder := &struct {
+		Name string `fig:"reg[name]"`
+		Port int    `fig:"reg[port]"`
+		Host string `fig:"reg[host]"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Name != "first" || holder.Port != 8080 || holder.Host != "registered" {
+		t.Errorf("Unexpected values: %#v", holder)
+	}
+}
+
+func TestDotenvSource_Interpolation(t *testing.T) {
+	os.Setenv("FIG_SOURCE_HOST", "process")
+	defer os.Unsetenv("FIG_SOURCE_HOST")
+	const content = "FIG_SOURCE_URL=http://${FIG_SOURCE_HOST}:$FIG_SOURCE_PORT\n"
+
+	fromProcess, err := DotenvSource(strings.NewReader(content))
+	FatalIfError(func() error {
+		return err
+	})
+	if url, _ := fromProcess.Lookup("FIG_SOURCE_URL"); url != "http://process:" {
+		t.Errorf("Variables of the process expected: %v", url)
+	}
+
+	fromSources, err := DotenvSource(strings.NewReader(content), MapSource{"FIG_SOURCE_HOST": "map", "FIG_SOURCE_PORT": 8080})
+	FatalIfError(func() error {
+		return err
+	})
+	if url, _ := fromSources.Lookup("FIG_SOURCE_URL"); url != "http://map:8080" {
+		t.Errorf("Variables of sources expected: %v", url)
+	}
+
+	injector := New(false, WithEnvSources(MapSource{"FIG_SOURCE_HOST": "map"}))
+	FatalIfError(func() error {
+		return injector.LoadDotenv(strings.NewReader(content))
+	})
+	holder := &struct {
+		URL string `fig:"env[FIG_SOURCE_URL]"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.URL != "http://map:" {
+		t.Errorf("Env sources of the injector expected: %s", holder.URL)
+	}
+}
@@ -0,0 +1,303 @@
+package fig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type tagKeyKind int
+
+const (
+	// configuration with value: key[value]
+	valueTagKey tagKeyKind = iota
+	// configuration without value: key
+	flagTagKey
+	// configuration with true or false value, bare key means true
+	boolTagKey
+)
+
+var knownTagKeys = map[string]tagKeyKind{
+	IMPL_TAG_KEY:      valueTagKey,
+	ENV_TAG_KEY:       valueTagKey,
+	SKIP_TAG_KEY:      boolTagKey,
+	REG_TAG_KEY:       valueTagKey,
+	QUAL_TAG_KEY:      valueTagKey,
+	SIZE_TAG_KEY:      valueTagKey,
+	CAPACITY_TAG_KEY:  valueTagKey,
+	DEFAULT_TAG_KEY:   valueTagKey,
+	REQUIRED_TAG_KEY:  boolTagKey,
+	ALL_TAG_KEY:       flagTagKey,
+	QUALIFIED_TAG_KEY: flagTagKey,
+	FLAG_TAG_KEY:      valueTagKey,
+	USAGE_TAG_KEY:     valueTagKey,
+}
+
+// tagConfig is parsed `fig` tag: configurations in order they are defined.
+type tagConfig struct {
+	keys   []string
+	values map[string]string
+	// flags are configurations defined without value
+	flags map[string]bool
+}
+
+// parsedTags caches parsed `fig` tags, the same tag is parsed every time the struct is assembled.
+var parsedTags sync.Map
+
+type parsedTag struct {
+	conf *tagConfig
+	err  error
+}
+
+func parseFigTag(tag reflect.StructTag) (*tagConfig, error) {
+	figTag, ok := tag.Lookup(FIG_TAG)
+	if !ok {
+		return &tagConfig{}, nil
+	}
+	if cached, found := parsedTags.Load(figTag); found {
+		return cached.(parsedTag).conf, cached.(parsedTag).err
+	}
+	conf, err := parseConfig(figTag)
+	parsedTags.Store(figTag, parsedTag{conf: conf, err: err})
+	return conf, err
+}
+
+// parseConfig tokenizes value of `fig` tag. Configurations are separated by spaces and
+// have form of `key` or `key[value]`. Value can contain any characters, `]` and `\`
+// must be escaped with `\`.
+func parseConfig(conf string) (*tagConfig, error) {
+	parsed := &tagConfig{values: make(map[string]string), flags: make(map[string]bool)}
+	incorrect := func(reason string) error {
+		return FigError{
+			Cause:  "Invalid configuration in: " + conf + ": " + reason,
+			Error_: ErrorIncorrectTagConfiguration,
+		}
+	}
+
+	for pos := 0; pos < len(conf); {
+		if conf[pos] == ' ' {
+			pos++
+			continue
+		}
+		keyStart := pos
+		for pos < len(conf) && isTagKeyChar(rune(conf[pos])) {
+			pos++
+		}
+		key := conf[keyStart:pos]
+		if key == "" {
+			return nil, incorrect(fmt.Sprintf("unexpected character %q", conf[pos]))
+		}
+		if _, found := parsed.values[key]; found || parsed.flags[key] {
+			return nil, incorrect("duplicate configuration " + key)
+		}
+		parsed.keys = append(parsed.keys, key)
+
+		if pos == len(conf) || conf[pos] == ' ' {
+			parsed.flags[key] = true
+			continue
+		}
+		if conf[pos] != '[' {
+			return nil, incorrect(fmt.Sprintf("unexpected character %q after %s", conf[pos], key))
+		}
+		pos++
+		var value strings.Builder
+		closed := false
+		for ; pos < len(conf) && !closed; pos++ {
+			switch conf[pos] {
+			case '\\':
+				if pos+1 == len(conf) {
+					return nil, incorrect("unfinished escape sequence in " + key)
+				}
+				pos++
+				value.WriteByte(conf[pos])
+			case ']':
+				closed = true
+			default:
+				value.WriteByte(conf[pos])
+			}
+		}
+		if !closed {
+			return nil, incorrect("value of " + key + " is not closed with ]")
+		}
+		if value.Len() == 0 {
+			return nil, incorrect("value of " + key + " is empty")
+		}
+		if pos < len(conf) && conf[pos] != ' ' {
+			return nil, incorrect(fmt.Sprintf("unexpected character %q after %s", conf[pos], key))
+		}
+		parsed.values[key] = value.String()
+	}
+	return parsed, nil
+}
+
+func isTagKeyChar(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_' || char == '-' || char == '.'
+}
+
+// escapeTagValue escapes characters that have special meaning inside value of configuration.
+func escapeTagValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `]`, `\]`).Replace(value)
+}
+
+// validate returns error if there are configurations not known to fig and without registered handler
+// or configurations defined with or without value incorrectly.
+func (conf *tagConfig) validate(fig *Fig, fieldName string) error {
+	var unknown, incorrect []string
+	for _, key := range conf.keys {
+		kind, known := knownTagKeys[key]
+		switch {
+		case !known && fig.tagHandler(key) == nil:
+			unknown = append(unknown, key)
+		case !known:
+			// custom configurations are validated by their handlers
+		case kind == valueTagKey && conf.flags[key]:
+			incorrect = append(incorrect, key+" requires value")
+		case kind == flagTagKey && !conf.flags[key]:
+			incorrect = append(incorrect, key+" can't have value")
+		}
+	}
+	if len(unknown) > 0 {
+		return FigError{
+			Cause:  fmt.Sprintf("Unknown configuration of `fig` tag of field %s: %s", fieldName, strings.Join(unknown, ", ")),
+			Error_: ErrorIncorrectTagConfiguration,
+		}
+	}
+	if len(incorrect) > 0 {
+		return FigError{
+			Cause:  fmt.Sprintf("Incorrect configuration of `fig` tag of field %s: %s", fieldName, strings.Join(incorrect, ", ")),
+			Error_: ErrorIncorrectTagConfiguration,
+		}
+	}
+	return nil
+}
+
+type InjectStepTagValidation struct {
+	fig       *Fig
+	tag       reflect.StructTag
+	fieldName string
+}
+
+func NewTagValidation(fig *Fig, tag reflect.StructTag, fieldName string) *InjectStepTagValidation {
+	return &InjectStepTagValidation{fig: fig, tag: tag, fieldName: fieldName}
+}
+
+func (tagValidation *InjectStepTagValidation) Do() error {
+	conf, err := parseFigTag(tagValidation.tag)
+	if err != nil {
+		return err
+	}
+	return conf.validate(tagValidation.fig, tagValidation.fieldName)
+}
+
+func (tagValidation *InjectStepTagValidation) Break() bool {
+	return false
+}
+
+// TagHandler sets value of the field configured with custom key of `fig` tag.
+// It receives value of the configuration (empty if key is defined without value)
+// and injector that initializes the field. If handler returns true the field is
+// considered set and other configurations are not applied.
+type TagHandler func(field reflect.Value, value string, fig *Fig) (bool, error)
+
+// RegisterTagHandler registers handler for custom key of `fig` tag, e.g. `vault[secret/db]`.
+// Keys of fig configurations can't be used. Handlers are inherited by child injectors.
+func (fig *Fig) RegisterTagHandler(key string, handler TagHandler) error {
+	if handler == nil {
+		return FigError{Cause: "nil cannot be registered as tag handler", Error_: ErrorCannotBeRegistered}
+	}
+	if key == "" || strings.IndexFunc(key, func(char rune) bool { return !isTagKeyChar(char) }) >= 0 {
+		return FigError{Cause: "incorrect key of tag handler: " + key, Error_: ErrorCannotBeRegistered}
+	}
+	if _, known := knownTagKeys[key]; known {
+		return FigError{Cause: "key of fig configuration can't be used by tag handler: " + key, Error_: ErrorCannotBeRegistered}
+	}
+	fig.mu.Lock()
+	defer fig.mu.Unlock()
+	fig.tagHandlers[key] = handler
+	return nil
+}
+
+func (fig *Fig) tagHandler(key string) TagHandler {
+	for injector := fig; injector != nil; injector = injector.parent {
+		injector.mu.RLock()
+		handler, found := injector.tagHandlers[key]
+		injector.mu.RUnlock()
+		if found {
+			return handler
+		}
+	}
+	return nil
+}
+
+type InjectStepTagHandlerSetup struct {
+	fig                *Fig
+	tag                reflect.StructTag
+	holderElementField reflect.Value
+	fieldName          string
+	skip               bool
+	reason             InjectionReason
+	// key of configuration that set the field
+	source string
+}
+
+func NewTagHandlerSetup(fig *Fig, tag reflect.StructTag, holderElementField reflect.Value, fieldName string) *InjectStepTagHandlerSetup {
+	return &InjectStepTagHandlerSetup{fig: fig, tag: tag, holderElementField: holderElementField, fieldName: fieldName}
+}
+
+// Do calls handlers of custom configurations in order they are defined in the tag until one of them sets the field.
+// If none of them sets the field and there are no `reg` and `env` configurations `default` and `required` are applied.
+func (tagHandlerSetup *InjectStepTagHandlerSetup) Do() error {
+	// handlers are called for Lazy when it is resolved
+	if _, isLazy := lazyOf(tagHandlerSetup.holderElementField); isLazy {
+		return nil
+	}
+	conf, err := parseFigTag(tagHandlerSetup.tag)
+	if err != nil {
+		return err
+	}
+	var handledKeys []string
+	for _, key := range conf.keys {
+		if _, known := knownTagKeys[key]; known {
+			continue
+		}
+		handler := tagHandlerSetup.fig.tagHandler(key)
+		if handler == nil {
+			continue
+		}
+		handledKeys = append(handledKeys, key)
+		handled, err := handler(tagHandlerSetup.holderElementField, conf.values[key], tagHandlerSetup.fig)
+		if err != nil {
+			if _, isFigErr := err.(FigError); isFigErr {
+				return err
+			}
+			return FigError{
+				Cause:  fmt.Sprintf("Handler of %s configuration failed: %v", key, err),
+				Error_: ErrorTagHandlerFailed,
+			}
+		}
+		if handled {
+			tagHandlerSetup.skip = true
+			tagHandlerSetup.reason, tagHandlerSetup.source = ReasonTagHandler, key
+			return nil
+		}
+	}
+
+	_, hasReg := conf.values[REG_TAG_KEY]
+	_, hasEnv := conf.values[ENV_TAG_KEY]
+	if len(handledKeys) == 0 || hasReg || hasEnv {
+		return nil
+	}
+	defaultSet, err := setDefaultValue(tagHandlerSetup.tag, tagHandlerSetup.holderElementField,
+		tagHandlerSetup.fieldName, strings.Join(handledKeys, " "))
+	tagHandlerSetup.skip = defaultSet || err != nil
+	if defaultSet && err == nil {
+		tagHandlerSetup.reason, tagHandlerSetup.source = ReasonDefault, handledKeys[0]
+	}
+	return err
+}
+
+func (tagHandlerSetup *InjectStepTagHandlerSetup) Break() bool {
+	return tagHandlerSetup.skip
+}
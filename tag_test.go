@@ -0,0 +1,156 @@
+package fig
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	conf, err := parseConfig(`impl[a b]  qual[x\]y\\z] all required`)
+	FatalIfError(func() error {
+		return err
+	})
+	expectedValues := map[string]string{"impl": "a b", "qual": `x]y\z`}
+	if !reflect.DeepEqual(conf.values, expectedValues) {
+		t.Errorf("Unexpected values: %#v", conf.values)
+	}
+	if !conf.flags["all"] || !conf.flags["required"] || len(conf.flags) != 2 {
+		t.Errorf("Unexpected flags: %#v", conf.flags)
+	}
+	if !reflect.DeepEqual(conf.keys, []string{"impl", "qual", "all", "required"}) {
+		t.Errorf("Unexpected keys: %#v", conf.keys)
+	}
+}
+
+func TestParseConfig_Incorrect(t *testing.T) {
+	for _, conf := range []string{
+		`qual[x] qual[y]`,
+		`all all`,
+		`qual[x`,
+		`qual[x]y`,
+		`qual[]`,
+		`qual[x\`,
+		`[x]`,
+		`qual=x`,
+	} {
+		_, err := parseConfig(conf)
+		if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorIncorrectTagConfiguration {
+			t.Errorf("Error expected for %s: %v", conf, err)
+		}
+	}
+}
+
+func TestGetFigTagConfig_KeyIsNotMatchedInsideOtherKey(t *testing.T) {
+	value, found, err := getFigTagConfig(`fig:"myqual[x]"`, QUAL_TAG_KEY)
+	if found || err != nil {
+		t.Errorf("Configuration must not be found: %s, %v", value, err)
+	}
+}
+
+func TestInitialize_UnknownTagConfiguration(t *testing.T) {
+	injector := New(false)
+
+	err := injector.Initialize(&struct {
+		Name string `fig:"envv[NAME] defualt[x] skip"`
+	}{})
+	figErr, ok := err.(FigError)
+	if !ok || figErr.Error_ != ErrorIncorrectTagConfiguration ||
+		!strings.Contains(figErr.Cause, "of field Name: envv, defualt") {
+		t.Errorf("Error listing unknown configurations expected: %v", err)
+	}
+
+	for _, holder := range []interface{}{
+		&struct {
+			Name string `fig:"env"`
+		}{},
+		&struct {
+			Handlers []Handler `fig:"all[true]"`
+		}{},
+	} {
+		err := injector.Initialize(holder)
+		if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorIncorrectTagConfiguration {
+			t.Errorf("Incorrect configuration error expected for %T: %v", holder, err)
+		}
+	}
+}
+
+func TestInitialize_EscapedTagValue(t *testing.T) {
+	injector := New(false)
+	holder := &struct {
+		Pattern string `fig:"env[FIG_NOT_DEFINED_PATTERN] default[[a-z\\]+] required"`
+		Skipped string `fig:"skip"`
+	}{Skipped: "kept"}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Pattern != "[a-z]+" || holder.Skipped != "kept" {
+		t.Errorf("Unexpected values: %#v", holder)
+	}
+}
+
+func TestRegisterTagHandler(t *testing.T) {
+	secrets := map[string]string{"db/password": "secret"}
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.RegisterTagHandler("vault", func(field reflect.Value, value string, fig *Fig) (bool, error) {
+			secret, found := secrets[value]
+			if found {
+				field.SetString(secret)
+			}
+			return found, nil
+		})
+	})
+	FatalIfError(func() error {
+		return injector.RegisterTagHandler("upper", func(field reflect.Value, value string, fig *Fig) (bool, error) {
+			return false, nil
+		})
+	})
+
+	holder := &struct {
+		Password string `fig:"vault[db/password]"`
+		Fallback string `fig:"vault[db/user] upper default[admin]"`
+	}{}
+	child := injector.Child()
+	FatalIfError(func() error {
+		return child.Initialize(holder)
+	})
+	if holder.Password != "secret" || holder.Fallback != "admin" {
+		t.Errorf("Unexpected values: %#v", holder)
+	}
+	if graph := child.Graph(); len(graph.Nodes) != 0 {
+		t.Errorf("Unexpected graph: %#v", graph)
+	}
+
+	if err := New(false).Initialize(&struct {
+		Password string `fig:"vault[db/password]"`
+	}{}); err == nil {
+		t.Error("Error expected for configuration without registered handler")
+	}
+}
+
+func TestRegisterTagHandler_Errors(t *testing.T) {
+	injector := New(false)
+	noop := func(field reflect.Value, value string, fig *Fig) (bool, error) {
+		return false, nil
+	}
+	for key, handler := range map[string]TagHandler{"env": noop, "": noop, "with space": noop, "nil": nil} {
+		if err := injector.RegisterTagHandler(key, handler); err == nil {
+			t.Errorf("Registration error expected for key %q", key)
+		}
+	}
+
+	FatalIfError(func() error {
+		return injector.RegisterTagHandler("file", func(field reflect.Value, value string, fig *Fig) (bool, error) {
+			return false, errors.New("file not found: " + value)
+		})
+	})
+	err := injector.Initialize(&struct {
+		Cert string `fig:"file[cert.pem]"`
+	}{})
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorTagHandlerFailed ||
+		!strings.Contains(figErr.Cause, "file not found: cert.pem") {
+		t.Errorf("Error of handler expected: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package fig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ResolveOption configures selection of implementation by Resolve
+// the same way as `fig` tag configures it for fields.
+type ResolveOption func() string
+
+// Qualified selects implementation which Qualify method returns qualifier.
+func Qualified(qualifier string) ResolveOption {
+	return func() string {
+		return QUAL_TAG_KEY + "[" + escapeTagValue(qualifier) + "]"
+	}
+}
+
+// Impl selects implementation by full name of its type.
+func Impl(fullName string) ResolveOption {
+	return func() string {
+		return IMPL_TAG_KEY + "[" + escapeTagValue(fullName) + "]"
+	}
+}
+
+// Get returns value of type T resolved as if it was a field of holder passed to Initialize.
+func Get[T any](f *Fig) (T, error) {
+	return Resolve[T](f)
+}
+
+// GetContext is like Get but ctx is used as in InitializeContext. Constructor functions
+// and Init methods that resolve values from the same Fig should pass their context here.
+func GetContext[T any](ctx context.Context, f *Fig) (T, error) {
+	return ResolveContext[T](ctx, f)
+}
+
+// MustGet is like Get but panics if value can't be resolved.
+func MustGet[T any](f *Fig) T {
+	value, err := Get[T](f)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// Resolve returns value of type T selected with options.
+func Resolve[T any](f *Fig, opts ...ResolveOption) (T, error) {
+	return ResolveContext[T](context.Background(), f, opts...)
+}
+
+// ResolveContext is like Resolve but ctx is used as in InitializeContext.
+func ResolveContext[T any](ctx context.Context, f *Fig, opts ...ResolveOption) (T, error) {
+	var value T
+	if ctx == nil {
+		return value, FigError{Cause: "nil cannot be used as context", Error_: ErrorCannotBeHolder}
+	}
+	conf := make([]string, 0, len(opts))
+	for _, opt := range opts {
+		conf = append(conf, opt())
+	}
+	tag := reflect.StructTag(fmt.Sprintf("%s:%q", FIG_TAG, strings.Join(conf, " ")))
+	err := f.resolveValue(ctx, reflect.ValueOf(&value).Elem(), tag)
+	return value, err
+}
+
+// RegisterAs registers impl that is injected into fields of interface I,
+// but not into fields of other interfaces it implements.
+func RegisterAs[I any](f *Fig, impl I) error {
+	return f.RegisterAs(impl, (*I)(nil))
+}
@@ -0,0 +1,160 @@
+package fig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pavelmemory/fig/examples/justpackage/repos"
+)
+
+func TestGet(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(&providedConfig{DSN: "db"}, new(providedStore))
+	})
+
+	store, err := Get[*providedStore](injector)
+	FatalIfError(func() error {
+		return err
+	})
+	if store.Config == nil || store.Config.DSN != "db" {
+		t.Errorf("Assembled object expected: %#v", store)
+	}
+	if repo := MustGet[repos.UserRepo](injector); repo != store {
+		t.Errorf("Registered implementation expected: %#v", repo)
+	}
+
+	_, err = Get[Handler](injector)
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotDecideImplementation {
+		t.Errorf("Error about missing implementation expected: %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGet must panic if value can't be resolved")
+		}
+	}()
+	MustGet[Handler](injector)
+}
+
+func TestResolve(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(new(jsonHandler), new(xmlHandler))
+	})
+
+	handler, err := Resolve[Handler](injector, Qualified("application/xml"))
+	FatalIfError(func() error {
+		return err
+	})
+	if handler.Handle() != "xml" {
+		t.Errorf("Qualified implementation expected: %s", handler.Handle())
+	}
+
+	handler, err = Resolve[Handler](injector, Impl("github.com/pavelmemory/fig/jsonHandler"))
+	FatalIfError(func() error {
+		return err
+	})
+	if handler.Handle() != "json" {
+		t.Errorf("Implementation chosen by name expected: %s", handler.Handle())
+	}
+
+	if _, err = Resolve[Handler](injector); err == nil {
+		t.Error("Error expected if implementation can't be chosen")
+	}
+}
+
+func TestRegisterAs(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return RegisterAs[Handler](injector, new(handlerFirst))
+	})
+	FatalIfError(func() error {
+		return injector.Register(new(handlerSecond))
+	})
+
+	holder := &struct {
+		Handler  Handler   `fig:"impl[github.com/pavelmemory/fig/handlerFirst]"`
+		Handlers []Handler `fig:"all"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if holder.Handler.Handle() != "first" || len(holder.Handlers) != 2 {
+		t.Errorf("Unexpected handlers: %#v", holder)
+	}
+
+	if err := RegisterAs[*handlerFirst](injector, new(handlerFirst)); err == nil {
+		t.Error("Error expected for type that is not interface")
+	}
+}
+
+type reentrantEngine struct{}
+
+type reentrantCar struct {
+	Engine *reentrantEngine
+}
+
+type reentrantGarage struct {
+	Injector *Fig          `fig:"skip[true]"`
+	Car      *reentrantCar `fig:"skip[true]"`
+}
+
+func (rg *reentrantGarage) Init(ctx context.Context) (err error) {
+	rg.Car, err = GetContext[*reentrantCar](ctx, rg.Injector)
+	return err
+}
+
+func TestGetContext_FromProvidersAndInit(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.Register(&reentrantGarage{Injector: injector})
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func() *reentrantEngine {
+			return new(reentrantEngine)
+		})
+	})
+	FatalIfError(func() error {
+		return injector.Provide(func(ctx context.Context) (*reentrantCar, error) {
+			engine, err := GetContext[*reentrantEngine](ctx, injector)
+			return &reentrantCar{Engine: engine}, err
+		})
+	})
+
+	holder := &struct {
+		Garage *reentrantGarage
+		Car    *reentrantCar
+	}{}
+	FatalIfError(func() error {
+		return initializeWithTimeout(t, injector, holder)
+	})
+	if holder.Car == nil || holder.Car.Engine == nil || holder.Garage.Car != holder.Car {
+		t.Errorf("Objects resolved by providers and Init must be shared: %#v", holder)
+	}
+}
+
+func TestGet_NothingToResolve(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.RegisterValue("name", "fig")
+	})
+
+	if name, err := Get[string](injector); err == nil {
+		t.Errorf("Error expected for value without configuration: %q", name)
+	} else if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotDecideImplementation {
+		t.Errorf("Error about missing implementation expected: %v", err)
+	}
+	if _, err := Get[int](injector); err == nil {
+		t.Error("Error expected for value without configuration")
+	}
+
+	holder := &struct {
+		Name Lazy[string] `fig:"reg[name]"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+	if name, err := holder.Name.Get(); err != nil || name != "fig" {
+		t.Errorf("Registered value expected: %q, %v", name, err)
+	}
+}
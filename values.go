@@ -0,0 +1,59 @@
+package fig
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// LoadValuesJSON registers values of JSON object read from reader. Nested objects are flattened
+// into dotted keys, so value of {"db": {"pool": {"size": 10}}} is registered by key `db.pool.size`.
+// Numbers, strings and arrays are converted into type of the field at the time of injection.
+// Null values are ignored. If some keys were already registered their values are overridden
+// and ErrorRegisteredValueOverridden is returned.
+func (fig *Fig) LoadValuesJSON(reader io.Reader) error {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return FigError{Cause: "JSON object can't be decoded: " + err.Error(), Error_: ErrorCannotBeRegistered}
+	}
+	if values == nil {
+		return FigError{Cause: "JSON object expected, got null", Error_: ErrorCannotBeRegistered}
+	}
+
+	flattened := make(map[string]interface{})
+	flattenValues("", values, flattened)
+	return fig.registerLoadedValues(flattened)
+}
+
+func flattenValues(prefix string, values map[string]interface{}, flattened map[string]interface{}) {
+	for key, value := range values {
+		switch nested := value.(type) {
+		case nil:
+		case map[string]interface{}:
+			flattenValues(prefix+key+".", nested, flattened)
+		default:
+			flattened[prefix+key] = value
+		}
+	}
+}
+
+// registerLoadedValues registers all values even if some of them override already registered ones.
+func (fig *Fig) registerLoadedValues(values map[string]interface{}) error {
+	fig.mu.Lock()
+	defer fig.mu.Unlock()
+	var overridden []string
+	for key, value := range values {
+		if _, found := fig.registeredValues[key]; found {
+			overridden = append(overridden, key)
+		}
+		fig.registeredValues[key] = value
+	}
+	if len(overridden) > 0 {
+		sort.Strings(overridden)
+		return FigError{Cause: "overridden keys: " + strings.Join(overridden, ", "), Error_: ErrorRegisteredValueOverridden}
+	}
+	return nil
+}
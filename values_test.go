@@ -0,0 +1,76 @@
+package fig
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const valuesJSON = `{
+	"name": "service",
+	"debug": true,
+	"db": {
+		"host": "localhost",
+		"pool": {"size": 10, "timeout": "5s", "ratio": 0.75},
+		"replicas": ["r1", "r2"],
+		"ports": [5432, 5433],
+		"password": null
+	}
+}`
+
+func TestLoadValuesJSON(t *testing.T) {
+	injector := New(false)
+	FatalIfError(func() error {
+		return injector.LoadValuesJSON(strings.NewReader(valuesJSON))
+	})
+
+	holder := &struct {
+		Name     string        `fig:"reg[name]"`
+		Debug    bool          `fig:"reg[debug]"`
+		Host     string        `fig:"reg[db.host]"`
+		PoolSize int           `fig:"reg[db.pool.size]"`
+		SizeText string        `fig:"reg[db.pool.size]"`
+		Timeout  time.Duration `fig:"reg[db.pool.timeout]"`
+		Ratio    float32       `fig:"reg[db.pool.ratio]"`
+		Replicas []string      `fig:"reg[db.replicas]"`
+		Ports    []uint16      `fig:"reg[db.ports]"`
+		Password string        `fig:"reg[db.password] default[none]"`
+	}{}
+	FatalIfError(func() error {
+		return injector.Initialize(holder)
+	})
+
+	if holder.Name != "service" || !holder.Debug || holder.Host != "localhost" ||
+		holder.PoolSize != 10 || holder.SizeText != "10" || holder.Timeout != 5*time.Second || holder.Ratio != 0.75 ||
+		strings.Join(holder.Replicas, ",") != "r1,r2" || len(holder.Ports) != 2 || holder.Ports[1] != 5433 ||
+		holder.Password != "none" {
+		t.Errorf("Unexpected values: %#v", holder)
+	}
+}
+
+func TestLoadValuesJSON_Errors(t *testing.T) {
+	injector := New(false)
+	for _, incorrect := range []string{`[1, 2]`, `null`, `{"a": `} {
+		err := injector.LoadValuesJSON(strings.NewReader(incorrect))
+		if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorCannotBeRegistered {
+			t.Errorf("Error expected for %s: %v", incorrect, err)
+		}
+	}
+
+	FatalIfError(func() error {
+		return injector.LoadValuesJSON(strings.NewReader(`{"db": {"host": "localhost", "port": 5432}}`))
+	})
+	err := injector.LoadValuesJSON(strings.NewReader(`{"db": {"port": 5433}}`))
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorRegisteredValueOverridden || figErr.Cause != "overridden keys: db.port" {
+		t.Errorf("Error about overridden value expected: %v", err)
+	}
+
+	holder := &struct {
+		Port  int8 `fig:"reg[db.port]"`
+		Other int  `fig:"reg[db.host]"`
+	}{}
+	err = injector.Initialize(holder)
+	if figErr, ok := err.(FigError); !ok || figErr.Error_ != ErrorIncorrectTagConfiguration {
+		t.Errorf("Error about value that can't be converted expected: %v", err)
+	}
+}